@@ -0,0 +1,436 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	passwordIterations int = 100000
+	passwordMinLength  int = 5
+	loginAttempts      int = 3
+)
+
+var (
+	errNoPlayer  = errors.New("no saved player with that name")
+	errNameTaken = errors.New("a player with that name already exists")
+)
+
+// on-disk record for one player, account details and the character they play
+type playerFile struct {
+	Name    string        `json:"name"`
+	Salt    string        `json:"salt"`
+	Hash    string        `json:"hash"`
+	Iter    int           `json:"iter"`
+	Created time.Time     `json:"created"`
+	Saved   time.Time     `json:"saved"`
+	Admin   bool          `json:"admin,omitempty"`
+	Char    characterData `json:"char"`
+}
+
+type characterData struct {
+	Class     string               `json:"class"`
+	Desc      string               `json:"desc"`
+	Status    int                  `json:"status"`
+	Str       int                  `json:"str"`
+	Dex       int                  `json:"dex"`
+	Con       int                  `json:"con"`
+	Intl      int                  `json:"intl"`
+	Wis       int                  `json:"wis"`
+	Cha       int                  `json:"cha"`
+	Fort      int                  `json:"fort"`
+	Ref       int                  `json:"ref"`
+	Wil       int                  `json:"wil"`
+	Att       int                  `json:"att"`
+	Dam       int                  `json:"dam"`
+	Hp        int                  `json:"hp"`
+	Mana      int                  `json:"mana"`
+	Moves     int                  `json:"moves"`
+	Exp       int                  `json:"exp"`
+	MaxHp     int                  `json:"maxHp"`
+	MaxMana   int                  `json:"maxMana"`
+	MaxMoves  int                  `json:"maxMoves"`
+	Gold      int                  `json:"gold"`
+	Room      int                  `json:"room"`
+	Inv       []savedItem          `json:"inv"`
+	Eq        map[string]savedItem `json:"eq"`
+	Aliases   map[string]string    `json:"aliases,omitempty"`
+	Prompt    string               `json:"prompt,omitempty"`
+	Compact   bool                 `json:"compact,omitempty"`
+	BlankLine bool                 `json:"blankLine,omitempty"`
+	// only found in older files, prompt replaced it
+	StatusPrompt bool `json:"statusPrompt,omitempty"`
+}
+
+// items are saved by prototype id and rebuilt from the prototype on load
+type savedItem struct {
+	ID       int         `json:"id"`
+	UID      string      `json:"uid"`
+	State    string      `json:"state,omitempty"`
+	Contents []savedItem `json:"contents,omitempty"`
+}
+
+func playerPath(name string) string {
+	return filepath.Join(cfg.PlayerDir, strings.ToLower(name)+".json")
+}
+
+func loadPlayerFile(name string) (*playerFile, error) {
+	data, err := os.ReadFile(playerPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNoPlayer
+	}
+	if err != nil {
+		return nil, err
+	}
+	pf := &playerFile{}
+	if err := json.Unmarshal(data, pf); err != nil {
+		return nil, fmt.Errorf("%s: %v", playerPath(name), err)
+	}
+	return pf, nil
+}
+
+// writes to a temp file first so a crash mid-save never leaves a truncated file
+func (pf *playerFile) write() error {
+	if err := os.MkdirAll(cfg.PlayerDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pf, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(playerPath(pf.Name), data)
+}
+
+// writes a new player's first save, failing with errNameTaken if someone else made theirs first
+func (pf *playerFile) create() error {
+	if err := os.MkdirAll(cfg.PlayerDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(playerPath(pf.Name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return errNameTaken
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// the name is ours now, fill it in the same way every later save will
+	return pf.write()
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (pf *playerFile) setPassword(pw string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	pf.Salt = hex.EncodeToString(salt)
+	pf.Iter = passwordIterations
+	hash, err := hashPassword(pw, salt, pf.Iter)
+	if err != nil {
+		return err
+	}
+	pf.Hash = hex.EncodeToString(hash)
+	return nil
+}
+
+func (pf *playerFile) checkPassword(pw string) bool {
+	salt, err := hex.DecodeString(pf.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(pf.Hash)
+	if err != nil {
+		return false
+	}
+	got, err := hashPassword(pw, salt, pf.Iter)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// PBKDF2 with HMAC-SHA256 and a 32 byte key
+func hashPassword(pw string, salt []byte, iter int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, pw, salt, iter, sha256.Size)
+}
+
+// saves the user's character, keeping the account details already on disk
+func (u *User) save() error {
+	pf, err := loadPlayerFile(u.name)
+	if err != nil {
+		return err
+	}
+	pf.Char = u.char.toData(u.room)
+	pf.Admin = u.admin
+	pf.Saved = time.Now()
+	return pf.write()
+}
+
+func saveAllUsers(w *World) {
+	for _, u := range w.users {
+		if err := u.save(); err != nil {
+			log.Printf("Error saving %s: %v", u.name, err)
+		}
+	}
+}
+
+func (c *Character) toData(r *Room) characterData {
+	cd := characterData{
+		Class: c.class, Desc: c.desc, Status: c.status,
+		Str: c.str, Dex: c.dex, Con: c.con, Intl: c.intl, Wis: c.wis, Cha: c.cha,
+		Fort: c.fort, Ref: c.ref, Wil: c.wil, Att: c.att, Dam: c.dam,
+		Hp: c.hp, Mana: c.mana, Moves: c.moves, Exp: c.exp, Gold: c.gold,
+		MaxHp: c.maxHp, MaxMana: c.maxMana, MaxMoves: c.maxMoves,
+		Inv:       []savedItem{},
+		Eq:        map[string]savedItem{},
+		Aliases:   c.aliases,
+		Prompt:    c.prompt,
+		Compact:   c.compact,
+		BlankLine: c.blankLine,
+	}
+	if r != nil {
+		cd.Room = r.id
+	}
+	cd.Inv = saveItems(c.inv)
+	for s, i := range c.eq {
+		cd.Eq[s] = saveItem(i)
+	}
+	return cd
+}
+
+// rebuilds a character for u from saved data, items are cloned from their prototypes
+func (cd *characterData) toCharacter(u *User, w *World) *Character {
+	c := u.initChar()
+	c.class, c.desc, c.status = cd.Class, cd.Desc, cd.Status
+	c.str, c.dex, c.con, c.intl, c.wis, c.cha = cd.Str, cd.Dex, cd.Con, cd.Intl, cd.Wis, cd.Cha
+	c.fort, c.ref, c.wil, c.att, c.dam = cd.Fort, cd.Ref, cd.Wil, cd.Att, cd.Dam
+	c.hp, c.mana, c.moves, c.exp, c.gold = cd.Hp, cd.Mana, cd.Moves, cd.Exp, cd.Gold
+	c.maxHp, c.maxMana, c.maxMoves = cd.MaxHp, cd.MaxMana, cd.MaxMoves
+	c.prompt, c.compact, c.blankLine = cd.Prompt, cd.Compact, cd.BlankLine
+	if cd.StatusPrompt && c.prompt == "" {
+		c.prompt = statusPrompt
+	}
+	// characters used to be made with a placeholder description
+	if c.desc == "ToDo" {
+		c.desc = ""
+	}
+	c.fillDefaults()
+	for n, a := range cd.Aliases {
+		c.aliases[n] = a
+	}
+	for _, si := range cd.Inv {
+		if i := si.restore(u, w); i != nil {
+			c.inv = append(c.inv, i)
+		}
+	}
+	for s, si := range cd.Eq {
+		if i := si.restore(u, w); i != nil {
+			c.eq[s] = i
+		}
+	}
+	// only base stats are saved, what's worn is worked out again from the equipment
+	c.recalcEffects()
+	return c
+}
+
+// rebuilds the item from its prototype inside loc, along with anything it held
+func (si savedItem) restore(loc Location, w *World) *Item {
+	proto := getProtoByID(w.items, si.ID)
+	if proto == nil {
+		log.Printf("%s had item id %d which no longer exists, dropping it", loc.getName(), si.ID)
+		return nil
+	}
+	i := &Item{}
+	i.cloneItem(proto)
+	if si.UID != "" {
+		i.uID = si.UID
+	}
+	i.loc = loc.getLocation()
+	if s := i.store; s != nil && s.closable && si.State != "" {
+		s.setState(si.State)
+	}
+	addItem(w.items, i)
+	for _, ci := range si.Contents {
+		if c := ci.restore(i, w); c != nil {
+			i.add(c)
+		}
+	}
+	return i
+}
+
+// items without a prototype, like corpses, can't be rebuilt so what they hold is saved in their place
+func saveItems(items []*Item) []savedItem {
+	saved := []savedItem{}
+	for _, i := range items {
+		if i.id == 0 {
+			saved = append(saved, saveItems(i.contents)...)
+			continue
+		}
+		saved = append(saved, saveItem(i))
+	}
+	return saved
+}
+
+func saveItem(i *Item) savedItem {
+	si := savedItem{ID: i.id, UID: i.uID, Contents: saveItems(i.contents)}
+	// whether a container was left open, closed or locked
+	if s := i.store; s != nil && s.closable {
+		si.State = s.state()
+	}
+	return si
+}
+
+// returns the prototype (instance 0) of the item with id, nil if there is none
+func getProtoByID(items map[string]map[int]*Item, id int) *Item {
+	for _, m := range items {
+		if m[0] != nil && m[0].id == id {
+			return m[0]
+		}
+	}
+	return nil
+}
+
+func isValidName(name string) bool {
+	if len(name) < cfg.NameMin || len(name) > cfg.NameMax {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) || r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func isOnline(name string, w *World) bool {
+	return w.findUser(name) != nil
+}
+
+// runs the login or new character prompts, returns the player's name and their saved file
+func loginFromSession(s *Session) (*playerFile, error) {
+	s.Write(fmt.Sprintf("Welcome to %s\r\n", cfg.Name))
+	for {
+		s.Write("What are you called?")
+		name, err := s.ReadLine()
+		if err != nil {
+			fmt.Println("User Disconnected During Login")
+			s.Close()
+			return nil, err
+		}
+		if !isValidName(name) {
+			s.Write(fmt.Sprintf("Names need to be %d - %d letters\r\n", cfg.NameMin, cfg.NameMax))
+			continue
+		}
+		name = strings.ToUpper(name[:1]) + strings.ToLower(name[1:])
+
+		pf, err := loadPlayerFile(name)
+		// admin names are kept for characters that already exist, so no one can turn up and take one
+		if err == errNoPlayer && cfg.isAdmin(name) {
+			s.Write("That name is reserved.\r\n")
+			continue
+		}
+		if err == errNoPlayer {
+			pf, err = newPlayerFromSession(s, name)
+			if err != nil {
+				return nil, err
+			}
+			if pf == nil {
+				continue
+			}
+			return pf, nil
+		}
+		if err != nil {
+			log.Println("Error loading player", err)
+			s.Write("That character could not be loaded.\r\n")
+			continue
+		}
+		for i := 0; i < loginAttempts; i++ {
+			s.Write("Password:")
+			pw, err := s.ReadPassword()
+			if err != nil {
+				s.Close()
+				return nil, err
+			}
+			if pf.checkPassword(pw) {
+				return pf, nil
+			}
+			s.Write("Wrong password.\r\n")
+		}
+		s.Write("Too many failed attempts.\r\n")
+		s.Close()
+		return nil, fmt.Errorf("too many failed logins for %s from %s", name, s.conn.RemoteAddr())
+	}
+}
+
+// asks for and confirms a password for a new character, returns nil if the user backs out
+func newPlayerFromSession(s *Session, name string) (*playerFile, error) {
+	s.Write(fmt.Sprintf("A new adventurer! Do you want to be called %s? (y/n)", name))
+	yn, err := s.ReadLine()
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(strings.ToLower(yn), "y") {
+		return nil, nil
+	}
+	for {
+		s.Write("Choose a password:")
+		pw, err := s.ReadPassword()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if len(pw) < passwordMinLength {
+			s.Write(fmt.Sprintf("Passwords need to be at least %d characters\r\n", passwordMinLength))
+			continue
+		}
+		s.Write("Type it again:")
+		again, err := s.ReadPassword()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if again != pw {
+			s.Write("Passwords don't match.\r\n")
+			continue
+		}
+		pf := &playerFile{Name: name, Created: time.Now()}
+		if err := pf.setPassword(pw); err != nil {
+			return nil, err
+		}
+		pf.Char = characterData{Room: cfg.StartRoom}
+		// two people can pick the same new name at once, only one of them gets it
+		err = pf.create()
+		if err == errNameTaken {
+			s.Write("That name was taken while you were choosing a password.\r\n")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Println("New player created:", name)
+		return pf, nil
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+)
+
+// PBKDF2-HMAC-SHA256 vectors. the 64 byte outputs in RFC 7914 section 11 start with the 32 bytes
+// a single block gives, the rest are the commonly published RFC 6070 style ones for SHA-256
+func TestHashPasswordVectors(t *testing.T) {
+	vectors := []struct {
+		pw, salt string
+		iter     int
+		want     string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"},
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, v := range vectors {
+		got, err := hashPassword(v.pw, []byte(v.salt), v.iter)
+		if err != nil {
+			t.Fatalf("hashPassword(%q, %q, %d): %v", v.pw, v.salt, v.iter, err)
+		}
+		if hex.EncodeToString(got) != v.want {
+			t.Errorf("hashPassword(%q, %q, %d) = %x, want %s", v.pw, v.salt, v.iter, got, v.want)
+		}
+	}
+}
+
+// a hash as saved in a player file lets its player in and no one else
+func TestCheckPasswordSavedHash(t *testing.T) {
+	pf := &playerFile{
+		Salt: "8f3a1c0b7e6d5a4938271605f4e3d2c1",
+		Iter: 100000,
+		Hash: "830437f0e86eabee10b1daa4aeb09145f2d8413783c7b2307206f00bf64efd16",
+	}
+	if !pf.checkPassword("hunter2") {
+		t.Error("saved hash rejected its password")
+	}
+	if pf.checkPassword("hunter3") {
+		t.Error("saved hash accepted the wrong password")
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	pf := &playerFile{}
+	if err := pf.setPassword("swordfish"); err != nil {
+		t.Fatal(err)
+	}
+	if pf.Iter != passwordIterations || len(pf.Salt) != 32 {
+		t.Errorf("got iter %d and salt %q", pf.Iter, pf.Salt)
+	}
+	if !pf.checkPassword("swordfish") || pf.checkPassword("swordfist") {
+		t.Error("checkPassword doesn't match setPassword")
+	}
+}
+
+// only the first of two new players picking the same name gets it
+func TestCreatePlayerFileNameTaken(t *testing.T) {
+	cfg = defaultConfig()
+	cfg.PlayerDir = filepath.Join(t.TempDir(), "players")
+	first := &playerFile{Name: "Alpha", Char: characterData{Class: "Warrior"}}
+	if err := first.create(); err != nil {
+		t.Fatal(err)
+	}
+	second := &playerFile{Name: "Alpha", Char: characterData{Class: "Cleric"}}
+	if err := second.create(); err != errNameTaken {
+		t.Fatalf("second create got %v", err)
+	}
+	pf, err := loadPlayerFile("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Char.Class != "Warrior" {
+		t.Errorf("saved class %q, the second create overwrote the first", pf.Char.Class)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// point buy: every score starts at 8 and costs more the higher it goes
+	pointBuyPoints int = 27
+	pointBuyMin    int = 8
+	pointBuyMax    int = 15
+)
+
+// what raising a score to each value from 8 costs
+var pointBuyCost = map[int]int{8: 0, 9: 1, 10: 2, 11: 3, 12: 4, 13: 5, 14: 7, 15: 9}
+
+var statNames = []string{"str", "dex", "con", "int", "wis", "cha"}
+
+// a class players can pick when they make a character, loaded from the world files
+type Class struct {
+	name  string
+	desc  string
+	hp    int
+	mana  int
+	moves int
+	fort  int
+	ref   int
+	wil   int
+	att   int
+	// item prototypes new characters of the class start with, worn if there's room
+	equip []int
+}
+
+// the class called name, or the first one it's the start of
+func (w *World) findClass(name string) *Class {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	for _, c := range w.classes {
+		if strings.ToLower(c.name) == name {
+			return c
+		}
+	}
+	for _, c := range w.classes {
+		if strings.HasPrefix(strings.ToLower(c.name), name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// 4d6 dropping the lowest die, for each of the six attributes
+func rollStats() []int {
+	stats := make([]int, len(statNames))
+	for n := range stats {
+		dice := []int{rollDice(1, 6), rollDice(1, 6), rollDice(1, 6), rollDice(1, 6)}
+		sort.Ints(dice)
+		stats[n] = dice[1] + dice[2] + dice[3]
+	}
+	return stats
+}
+
+func statLine(stats []int) string {
+	parts := []string{}
+	for n, s := range stats {
+		parts = append(parts, fmt.Sprintf("%s %d", capFirst(statNames[n]), s))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// six scores for point buy, with the reason they won't do if they don't
+func parsePointBuy(line string) ([]int, string) {
+	f := strings.Fields(line)
+	if len(f) != len(statNames) {
+		return nil, fmt.Sprintf("Give six scores, one each for %s.", strings.Join(statNames, ", "))
+	}
+	stats := []int{}
+	spent := 0
+	for _, s := range f {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < pointBuyMin || n > pointBuyMax {
+			return nil, fmt.Sprintf("Scores have to be from %d to %d.", pointBuyMin, pointBuyMax)
+		}
+		stats = append(stats, n)
+		spent += pointBuyCost[n]
+	}
+	if spent > pointBuyPoints {
+		return nil, fmt.Sprintf("That costs %d points, you only have %d.", spent, pointBuyPoints)
+	}
+	return stats, ""
+}
+
+// the prompts a new character goes through: a class, then how to get their attributes,
+// then either rolling them or buying them with points
+func creationPrompt(w *World) *Prompt {
+	names := []string{}
+	for _, c := range w.classes {
+		names = append(names, c.name)
+	}
+	var class *Class
+	p := &Prompt{
+		name: "Character creation",
+		questions: []Question{
+			{fmt.Sprintf("Choose a class: %s. Type 'help <class>' to hear more about one.", strings.Join(names, ", ")), func(u *User, a string) (string, string) {
+				if f := strings.Fields(a); len(f) == 2 && strings.EqualFold(f[0], "help") {
+					if c := w.findClass(f[1]); c != nil {
+						return a, fmt.Sprintf("%s: %s", c.name, c.desc)
+					}
+				}
+				if class = w.findClass(a); class == nil {
+					return a, fmt.Sprintf("'%s' isn't one of the classes.", a)
+				}
+				return class.name, ""
+			}},
+			{"Roll your attributes, or buy them with points? (roll/buy)", func(u *User, a string) (string, string) {
+				switch strings.ToLower(a) {
+				case "r", "roll":
+					return "roll", ""
+				case "b", "buy":
+					return "buy", ""
+				}
+				return a, "Type roll or buy."
+			}},
+		},
+	}
+	p.done = func(u *User, answers []string) {
+		if answers[1] == "roll" {
+			stats := rollStats()
+			u.session.WriteLine("You rolled " + statLine(stats))
+			u.pushHandler(rollPrompt(class, stats, w))
+			return
+		}
+		costs := []string{}
+		for n := pointBuyMin; n <= pointBuyMax; n++ {
+			costs = append(costs, fmt.Sprintf("%d:%d", n, pointBuyCost[n]))
+		}
+		u.session.WriteLine(fmt.Sprintf("You have %d points to spend. Each score costs %s.", pointBuyPoints, strings.Join(costs, " ")))
+		u.pushHandler(buyPrompt(class, w))
+	}
+	p.aborted = restartCreation(w)
+	return p
+}
+
+// creation can't be skipped, aborting starts it over
+func restartCreation(w *World) func(u *User) {
+	return func(u *User) {
+		u.session.WriteLine("Your character isn't finished yet, let's start again.")
+		u.pushHandler(creationPrompt(w))
+	}
+}
+
+func rollPrompt(class *Class, stats []int, w *World) *Prompt {
+	return &Prompt{
+		name: "Rolling attributes",
+		questions: []Question{
+			{"Keep these? (keep, or reroll)", func(u *User, a string) (string, string) {
+				switch strings.ToLower(a) {
+				case "k", "keep", "y", "yes":
+					return a, ""
+				case "r", "reroll", "n", "no":
+					stats = rollStats()
+					return a, "Rolled " + statLine(stats)
+				}
+				return a, "Type keep or reroll. You have " + statLine(stats)
+			}},
+		},
+		done: func(u *User, answers []string) {
+			u.char.finishCreation(class, stats, w)
+		},
+		aborted: restartCreation(w),
+	}
+}
+
+func buyPrompt(class *Class, w *World) *Prompt {
+	var stats []int
+	return &Prompt{
+		name: "Buying attributes",
+		questions: []Question{
+			{fmt.Sprintf("Enter six scores for %s, each %d to %d, like 15 14 13 12 10 8.", strings.Join(statNames, " "), pointBuyMin, pointBuyMax), func(u *User, a string) (string, string) {
+				var why string
+				stats, why = parsePointBuy(a)
+				return a, why
+			}},
+		},
+		done: func(u *User, answers []string) {
+			u.char.finishCreation(class, stats, w)
+		},
+		aborted: restartCreation(w),
+	}
+}
+
+// sets c up as a fresh member of class: attributes, the saves and pools they lead to, and starting gear
+func (c *Character) finishCreation(class *Class, stats []int, w *World) {
+	c.class = class.name
+	c.str, c.dex, c.con, c.intl, c.wis, c.cha = stats[0], stats[1], stats[2], stats[3], stats[4], stats[5]
+	c.fort = class.fort + statBonus(c.con)
+	c.ref = class.ref + statBonus(c.dex)
+	c.wil = class.wil + statBonus(c.wis)
+	c.att = class.att
+	c.maxHp = atLeast(class.hp+statBonus(c.con)*2, 1)
+	c.maxMana = atLeast(class.mana+statBonus(c.intl)*2, 0)
+	c.maxMoves = atLeast(class.moves+statBonus(c.dex)*5, 1)
+	c.hp, c.mana, c.moves = c.maxHp, c.maxMana, c.maxMoves
+	u := c.user
+	u.session.WriteLine(fmt.Sprintf("You are now %s the %s.", color("cyan", c.name), class.name))
+	for _, id := range class.equip {
+		proto := getProtoByID(w.items, id)
+		if proto == nil {
+			continue
+		}
+		i := &Item{}
+		i.cloneItem(proto)
+		i.loc = u.getLocation()
+		c.inv = append(c.inv, i)
+		addItem(w.items, i)
+		if c.eq[i.slot] == nil {
+			wearItem(u, i)
+		}
+	}
+	if err := u.save(); err != nil {
+		u.session.WriteLine(color("magenta", "Your character couldn't be saved: "+err.Error()))
+	}
+	u.room.sendText(u)
+}
+
+func atLeast(n, min int) int {
+	if n < min {
+		return min
+	}
+	return n
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	startingHp    int = 20
+	startingMana  int = 10
+	startingMoves int = 50
+	startingStat  int = 10
+
+	combatRoundSeconds int = 3
+	baseArmorClass     int = 10
+	fleeChance         int = 50
+)
+
+// fills anything a saved or brand new character is missing
+func (c *Character) fillDefaults() {
+	for _, s := range []*int{&c.str, &c.dex, &c.con, &c.intl, &c.wis, &c.cha} {
+		if *s <= 0 {
+			*s = startingStat
+		}
+	}
+	if c.maxHp <= 0 {
+		c.maxHp = startingHp
+		c.hp = startingHp
+	}
+	if c.maxMana <= 0 {
+		c.maxMana = startingMana
+		c.mana = startingMana
+	}
+	if c.maxMoves <= 0 {
+		c.maxMoves = startingMoves
+		c.moves = startingMoves
+	}
+}
+
+// the room a character is standing in
+func (c *Character) getRoom() *Room {
+	if c.user != nil {
+		return c.user.room
+	}
+	return c.room
+}
+
+// queues msg for the character's player, if there is one
+func (c *Character) send(msg string) {
+	if c.user != nil {
+		c.user.send(msg)
+	}
+}
+
+// queues msg for everyone in r except the characters listed
+func (r *Room) sendAll(msg string, except ...*Character) {
+	for _, u := range r.users {
+		skip := false
+		for _, c := range except {
+			if u.char == c {
+				skip = true
+			}
+		}
+		if !skip {
+			u.send(msg)
+		}
+	}
+}
+
+// standard d20 style modifier for an attribute
+func statBonus(stat int) int {
+	return (stat - 10) / 2
+}
+
+func rollDice(qty int, sides int) int {
+	roll := 0
+	for i := 0; i < qty; i++ {
+		roll += rand.Intn(sides) + 1
+	}
+	return roll
+}
+
+// armor class is the base plus dex plus the ac of everything worn, less any encumbrance penalty
+func (c *Character) armorClass() int {
+	ac := baseArmorClass + statBonus(c.stats().dex) - c.encumbrance().penalty
+	for _, i := range c.eq {
+		ac += i.ac
+	}
+	return ac
+}
+
+// weapons the character swings each round, empty when fighting bare handed
+func (c *Character) weapons() []*Item {
+	if i := c.eq[holdBSlot]; i != nil && i.isWeapon() {
+		return []*Item{i}
+	}
+	wpns := []*Item{}
+	for _, s := range []string{holdRSlot, holdLSlot} {
+		if i := c.eq[s]; i != nil && i.isWeapon() {
+			wpns = append(wpns, i)
+		}
+	}
+	return wpns
+}
+
+// one swing per weapon against the target
+func (c *Character) attack(t *Character, w *World) {
+	wpns := c.weapons()
+	s := c.stats()
+	if len(wpns) == 0 {
+		wpns = append(wpns, nil)
+	}
+	r := c.getRoom()
+	for _, wpn := range wpns {
+		if t.hp <= 0 {
+			return
+		}
+		yours, theirs := "your fists", "their fists"
+		if wpn != nil {
+			yours, theirs = color("cyan", wpn.short), color("cyan", wpn.short)
+		}
+		if rollDice(1, 20)+s.att+statBonus(s.str)-c.encumbrance().penalty < t.armorClass() {
+			c.send(fmt.Sprintf("You swing %s at %s and miss.", yours, color("cyan", t.name)))
+			t.send(fmt.Sprintf("%s swings %s at you and misses.", color("cyan", capFirst(c.name)), theirs))
+			r.sendAll(fmt.Sprintf("%s swings %s at %s and misses.", color("cyan", capFirst(c.name)), theirs, color("cyan", t.name)), c, t)
+			continue
+		}
+		dmg := rollDice(1, 2)
+		if wpn != nil {
+			dmg = wpn.rollDamage()
+		}
+		dmg += s.dam + statBonus(s.str)
+		if dmg < 1 {
+			dmg = 1
+		}
+		t.hp -= dmg
+		c.send(fmt.Sprintf("You hit %s with %s. (%s)", color("cyan", t.name), yours, color("red", fmt.Sprint(dmg))))
+		t.send(fmt.Sprintf("%s hits you with %s. (%s)", color("cyan", capFirst(c.name)), theirs, color("red", fmt.Sprint(dmg))))
+		r.sendAll(fmt.Sprintf("%s hits %s with %s.", color("cyan", capFirst(c.name)), color("cyan", t.name), theirs), c, t)
+		if t.hp <= 0 {
+			t.die(c, w)
+		}
+	}
+}
+
+// ends every fight c is part of
+func (c *Character) stopFighting(w *World) {
+	c.fighting = nil
+	for _, u := range w.users {
+		if u.char.fighting == c {
+			u.char.fighting = nil
+		}
+	}
+	for _, m := range w.mobiles {
+		if m.char.fighting == c {
+			m.char.fighting = nil
+		}
+	}
+}
+
+// leaves a corpse holding everything c carried, players wake up back at the start and mobiles are gone for good
+func (c *Character) die(killer *Character, w *World) {
+	r := c.getRoom()
+	c.stopFighting(w)
+	c.send(color("red", "You have been KILLED!"))
+	r.sendAll(color("red", fmt.Sprintf("%s is dead! R.I.P.", capFirst(c.name))), c)
+	w.soundAround(r, 1, "You hear someone's death cry %s.")
+	if killer != nil {
+		killer.exp += c.maxHp
+		killer.send(fmt.Sprintf("You receive %s experience.", color("yellow", fmt.Sprint(c.maxHp))))
+	}
+
+	corpse := &Item{
+		name:     "the corpse of " + c.name,
+		keywords: append([]string{"corpse"}, nameKeywords(c.name)...),
+		desc:     "The lifeless remains of " + c.name + ". It looks like it has been picked over, or is about to be.",
+		uID:      "corpse|" + time.Now().Format(time.RFC3339),
+		store:    &Storage{},
+	}
+	corpse.fillDescs()
+	for _, i := range c.inv {
+		corpse.add(i)
+		i.loc = corpse.getLocation()
+	}
+	for _, i := range c.eq {
+		corpse.add(i)
+		i.loc = corpse.getLocation()
+	}
+	c.inv = []*Item{}
+	c.eq = map[string]*Item{}
+	c.mods = Effects{}
+	r.add(corpse)
+	corpse.loc = r.getLocation()
+
+	if c.mob != nil {
+		w.removeMobile(c.mob)
+		return
+	}
+	// back to full, counting whatever they're wearing, which is nothing now
+	c.hp = c.stats().hp
+	if u := c.user; u != nil {
+		start := getRoomByID(cfg.StartRoom, w)
+		r.removeUser(u)
+		start.addUser(u)
+		u.room = start
+		c.send("You awaken, naked and shaken, somewhere familiar.")
+		start.sendText(u)
+	}
+}
+
+func killCmd(usr *User, args []string, w *World) {
+	if len(args) < 2 || args[1] == "" {
+		usr.session.WriteLine(color("magenta", "Kill who?"))
+		return
+	}
+	t := usr.resolveChar(strings.Join(args[1:], " "), w)
+	if t == nil {
+		usr.session.WriteLine(color("magenta", "They aren't here."))
+		return
+	}
+	if usr.char.fighting == t {
+		usr.session.WriteLine(color("magenta", "You're doing the best you can!"))
+		return
+	}
+	usr.char.fighting = t
+	if t.fighting == nil {
+		t.fighting = usr.char
+	}
+	usr.session.WriteLine(fmt.Sprintf("You attack %s!", color("cyan", t.name)))
+	t.send(color("red", fmt.Sprintf("%s attacks you!", usr.name)))
+	usr.room.sendAll(fmt.Sprintf("%s attacks %s!", color("cyan", usr.name), color("cyan", t.name)), usr.char, t)
+}
+
+func fleeCmd(usr *User, w *World) {
+	if usr.char.fighting == nil {
+		usr.session.WriteLine(color("magenta", "You aren't fighting anyone."))
+		return
+	}
+	open := []*Exit{}
+	for _, ex := range usr.room.exits {
+		if ex.isOpen() && usr.sees(ex) && usr.char.barredBy(ex, w) == "" {
+			open = append(open, ex)
+		}
+	}
+	if len(open) == 0 || rand.Intn(100) >= fleeChance+statBonus(usr.char.stats().dex)*5 {
+		usr.session.WriteLine(color("magenta", "You try to flee but can't get away!"))
+		usr.room.sendAll(fmt.Sprintf("%s tries to flee but can't get away!", color("cyan", usr.name)), usr.char)
+		return
+	}
+	ex := open[rand.Intn(len(open))]
+	usr.char.stopFighting(w)
+	usr.session.WriteLine(color("red", "You flee head over heels!"))
+	moveUser(usr, usr.room, getRoomByID(ex.linkedID, w), ex.keyword, w)
+}
+
+// runs a round of every fight in progress, characters not fighting catch their breath
+func combatRound(w *World) {
+	chars := []*Character{}
+	for _, u := range w.users {
+		chars = append(chars, u.char)
+	}
+	for _, m := range w.mobiles {
+		chars = append(chars, m.char)
+	}
+	fighters := []*Character{}
+	for _, c := range chars {
+		if c.fighting != nil {
+			fighters = append(fighters, c)
+		} else {
+			s := c.stats()
+			if c.hp < s.hp {
+				c.hp++
+			}
+			if c.moves < s.moves {
+				c.moves++
+			}
+		}
+	}
+	for _, c := range fighters {
+		t := c.fighting
+		if t == nil || c.hp <= 0 {
+			continue
+		}
+		if t.getRoom() != c.getRoom() || t.hp <= 0 {
+			c.fighting = nil
+			continue
+		}
+		c.attack(t, w)
+	}
+}
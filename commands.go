@@ -0,0 +1,350 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// permission levels, a command can be used by anyone at or above its level
+const (
+	levelPlayer int = 0
+	levelAdmin  int = 1
+)
+
+// one entry in the command table. minAbbr is the shortest prefix of the name or an alias that
+// still runs it, 0 means the full name or an alias has to be typed
+type Command struct {
+	name    string
+	aliases []string
+	minAbbr int
+	level   int
+	args    string
+	desc    string
+	emote   bool
+	handler func(usr *User, args []string, w *World)
+}
+
+func (u *User) level() int {
+	if u.admin {
+		return levelAdmin
+	}
+	return levelPlayer
+}
+
+// every built in command. the order is the priority abbreviations are resolved in, so when
+// something typed is the start of more than one command the earlier one wins
+func builtinCommands() []*Command {
+	cmds := []*Command{
+		{name: "look", aliases: []string{"l", "exa", "examine"}, minAbbr: 1, args: "[exit|object|in <container>]",
+			desc: "Redisplays the room description, or looks at an exit, person or item, or into a container. Prioritizes players, inventory, ground, then EQ.", handler: lookCmd},
+	}
+	for _, d := range directions {
+		if !d.cmd {
+			continue
+		}
+		dir := d.name
+		// diagonals go by their short form, so 'so' still means south
+		abbr := 1
+		if len(d.abbr) > 1 {
+			abbr = 0
+		}
+		cmds = append(cmds, &Command{name: dir, aliases: []string{d.abbr}, minAbbr: abbr, desc: "Moves you " + dir + ".",
+			handler: func(usr *User, args []string, w *World) { isMoveValid(usr, dir, w) }})
+	}
+	return append(cmds, []*Command{
+		{name: "inventory", aliases: []string{"i"}, minAbbr: 1, desc: "Displays held items and how much you are carrying.", handler: invCmd},
+		{name: "go", minAbbr: 1, args: "<exit>", desc: "Moves you through an exit, like in, out, through or a portal.", handler: goCmd},
+		{name: "scan", minAbbr: 3, desc: "Shows who's about in the rooms nearby.", handler: scanCmd},
+		{name: "search", minAbbr: 3, desc: "Looks around for hidden exits. The wiser you are the more likely you'll find them.", handler: searchCmd},
+		{name: "say", minAbbr: 2, args: "<text>", desc: "Tries to speak to other users. Does not work if they're not here.", handler: sayCmd},
+		{name: "take", aliases: []string{"get"}, minAbbr: 1, args: "<item> [from <container>]", desc: "Takes an item off the floor, or out of a container.", handler: takeCmd},
+		{name: "put", minAbbr: 1, args: "<item> in <container>", desc: "Puts an item you're carrying into a container.", handler: putCmd},
+		{name: "wear", minAbbr: 3, args: "<item>", desc: "Tries to equip item.", handler: wearCmd},
+		{name: "remove", minAbbr: 2, args: "<item>", desc: "Removes an item you are wearing.", handler: removeCmd},
+		{name: "drop", minAbbr: 2, args: "<item>", desc: "Puts an item on the floor.", handler: dropCmd},
+		{name: "give", minAbbr: 2, args: "<item> <person>", desc: "Tries to give item to person.", handler: giveCmd},
+		{name: "open", minAbbr: 2, args: "<dir|door|container>", desc: "Opens a door or container.", handler: openCmd},
+		{name: "close", minAbbr: 2, args: "<dir|door|container>", desc: "Closes a door or container.", handler: openCmd},
+		{name: "lock", minAbbr: 3, args: "<dir|door|container>", desc: "Locks a closed door or container, if you have its key.", handler: openCmd},
+		{name: "unlock", minAbbr: 3, args: "<dir|door|container>", desc: "Unlocks a door or container, if you have its key.", handler: openCmd},
+		{name: "pick", minAbbr: 2, args: "<dir|door|container>", desc: "Tries to pick a lock without the key. Dexterity helps, some locks can't be picked.", handler: openCmd},
+		{name: "kill", aliases: []string{"attack"}, minAbbr: 1, args: "<target>", desc: "Starts a fight. Rounds happen every few seconds until someone dies or flees.", handler: killCmd},
+		{name: "flee", minAbbr: 2, desc: "Tries to escape a fight through a random exit.",
+			handler: func(usr *User, args []string, w *World) { fleeCmd(usr, w) }},
+		{name: "score", aliases: []string{"stats"}, minAbbr: 2, desc: "Shows your character sheet. Stats changed by what you're wearing show their base value in brackets.", handler: scoreCmd},
+		{name: "describe", minAbbr: 3, args: "[text|clear]", desc: "Shows or sets what others see when they look at you.", handler: describeCmd},
+		{name: "prompt", minAbbr: 3, args: "[on|off|default|compact|blank|<template>]",
+			desc: "Shows your prompt and its %tokens, or sets it to a template like '<%h/%Hhp %x>'. On adds hp, mana and moves, off or default goes back to just exits, compact and blank toggle typing on the prompt's line and a blank line before it.", handler: promptCmd},
+		{name: "eq", aliases: []string{"equipment"}, minAbbr: 2, desc: "Shows what you are wearing.", handler: eqCmd},
+		{name: "yell", minAbbr: 1, args: "<text>", desc: "Like say, except it can be heard a few rooms away in any direction.", handler: yellCmd},
+		{name: "shout", minAbbr: 2, args: "<text>", desc: "Like say/yell, but heard everywhere.", handler: shoutCmd},
+		{name: "help", minAbbr: 1, args: "[command]", desc: "Lists commands, or shows more about one.", handler: helpCmd},
+		{name: "who", minAbbr: 2, desc: "Lists all users online.", handler: whoCmd},
+		{name: "emotes", minAbbr: 2, desc: "Lists available emotes.", handler: emotesCmd},
+		{name: "alias", minAbbr: 3, args: "[name [commands]]", desc: "Lists your aliases, shows one, or makes name run commands. Separate several commands with ;.", handler: aliasCmd},
+		{name: "unalias", minAbbr: 3, args: "<name>", desc: "Forgets one of your aliases.", handler: unaliasCmd},
+		{name: "save", minAbbr: 3, desc: "Saves your character. Happens automatically every few minutes and when you quit.", handler: saveCmd},
+		{name: "quit", desc: "Saves your character and disconnects.", handler: quitCmd},
+		{name: "create", minAbbr: 2, level: levelAdmin, desc: "Starts an item creation prompt.",
+			handler: func(usr *User, args []string, w *World) { usr.pushHandler(itemCreationPrompt()) }},
+		{name: "slots", minAbbr: 2, desc: "Shows what equipment belongs to what slot for create.", handler: slotsCmd},
+		{name: "new", minAbbr: 3, level: levelAdmin, args: "<item id or name>", desc: "Tries to give you <item>. Has to exist in world item array.", handler: newCmd},
+		{name: "listitems", minAbbr: 5, level: levelAdmin, args: "[id|name]", desc: "Lists first instances w/o arg. Arg can be ID, name, or part of name. Is greedy.", handler: listItemsCmd},
+		{name: "snatch", level: levelAdmin, args: "<item id> <instance #>", desc: "Gives you instance of item no matter where its at.", handler: snatchCmd},
+		{name: "shutdown", level: levelAdmin, args: "[seconds|cancel]", desc: "Warns everyone, saves the world and stops the server.",
+			handler: func(usr *User, args []string, w *World) { shutdownCmd(usr, args, w, false) }},
+		{name: "reboot", level: levelAdmin, args: "[seconds|cancel]", desc: "Like shutdown, but restarts the server without dropping anyone's connection.",
+			handler: func(usr *User, args []string, w *World) { shutdownCmd(usr, args, w, true) }},
+	}...)
+}
+
+// builds the command table, emotes loaded from the world files are added after the built in commands.
+// an emote whose short form is taken just has to be typed out further
+func (w *World) registerCommands() error {
+	w.cmnds = builtinCommands()
+	if err := w.checkAbbreviations(); err != nil {
+		return err
+	}
+	for _, e := range w.emotes {
+		if w.exactCommand(e.name, levelAdmin) != nil {
+			log.Printf("Emote %s clashes with a command of the same name, skipping it", e.name)
+			continue
+		}
+		c := &Command{name: strings.ToLower(e.name), minAbbr: 2, args: "[target]", emote: true, handler: emoteCmd}
+		w.cmnds = append(w.cmnds, c)
+		for c.minAbbr < len(c.name) && w.findCommand(c.name[:c.minAbbr], levelAdmin) != c {
+			c.minAbbr++
+		}
+	}
+	return nil
+}
+
+// makes sure the short form help gives for each command runs that command and not one earlier in the table
+func (w *World) checkAbbreviations() error {
+	for _, c := range w.cmnds {
+		if c.minAbbr == 0 {
+			continue
+		}
+		if c.minAbbr > len(c.name) {
+			return fmt.Errorf("command %s can't be shortened to %d letters", c.name, c.minAbbr)
+		}
+		abbr := c.name[:c.minAbbr]
+		if found := w.findCommand(abbr, levelAdmin); found != c {
+			other := "nothing"
+			if found != nil {
+				other = found.name
+			}
+			return fmt.Errorf("command %s can be shortened to '%s', but that runs %s", c.name, abbr, other)
+		}
+	}
+	return nil
+}
+
+// looks up what the user typed: an exact name or alias first, then the first command it abbreviates
+func (w *World) findCommand(word string, level int) *Command {
+	word = strings.ToLower(word)
+	if c := w.exactCommand(word, level); c != nil {
+		return c
+	}
+	for _, c := range w.cmnds {
+		if c.level > level || c.minAbbr == 0 || len(word) < c.minAbbr {
+			continue
+		}
+		for _, n := range append([]string{c.name}, c.aliases...) {
+			if strings.HasPrefix(n, word) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func (w *World) exactCommand(word string, level int) *Command {
+	for _, c := range w.cmnds {
+		if c.level > level {
+			continue
+		}
+		if c.name == word {
+			return c
+		}
+		for _, a := range c.aliases {
+			if a == word {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	maxAliases     int = 30
+	maxStackedCmds int = 20
+)
+
+// everything a user types that isn't taken by an input handler comes through here. '!' repeats
+// the last line, ';' separates several commands and aliases are expanded before each one runs
+func (u *User) runLine(line string, w *World) {
+	if u.handleLine(line) {
+		return
+	}
+	if strings.TrimSpace(line) == "!" {
+		if u.lastLine == "" {
+			u.session.WriteLine(color("magenta", "There's nothing to repeat."))
+			return
+		}
+		line = u.lastLine
+	}
+	u.lastLine = line
+	// the ;s in an alias definition belong to the alias
+	if c := w.findCommand(strings.SplitN(strings.TrimSpace(line), " ", 2)[0], u.level()); c != nil && c.name == "alias" {
+		executeCmd(strings.TrimSpace(line), u, w)
+		return
+	}
+	cmds := []string{}
+	for _, part := range strings.Split(line, ";") {
+		cmds = append(cmds, u.char.expandAlias(strings.TrimSpace(part))...)
+	}
+	if len(cmds) > maxStackedCmds {
+		u.session.WriteLine(color("magenta", fmt.Sprintf("That's too many commands at once, the most is %d.", maxStackedCmds)))
+		return
+	}
+	for _, cmd := range cmds {
+		// a command earlier in the line may have started a prompt, the rest of the line answers it
+		if u.handleLine(cmd) {
+			continue
+		}
+		executeCmd(cmd, u, w)
+	}
+}
+
+// swaps an alias at the start of cmd for what it stands for, anything typed after it is kept on the end
+func (c *Character) expandAlias(cmd string) []string {
+	word, rest := cmd, ""
+	if n := strings.Index(cmd, " "); n >= 0 {
+		word, rest = cmd[:n], cmd[n:]
+	}
+	exp, ok := c.aliases[strings.ToLower(word)]
+	if !ok {
+		return []string{cmd}
+	}
+	cmds := []string{}
+	for _, part := range strings.Split(exp, ";") {
+		cmds = append(cmds, strings.TrimSpace(part))
+	}
+	cmds[len(cmds)-1] += rest
+	return cmds
+}
+
+func aliasCmd(usr *User, args []string, w *World) {
+	aliases := usr.char.aliases
+	if len(args) < 2 || args[1] == "" {
+		if len(aliases) == 0 {
+			usr.session.WriteLine("You have no aliases.")
+			return
+		}
+		names := []string{}
+		for n := range aliases {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		usr.session.WriteLine("Your aliases:")
+		for _, n := range names {
+			usr.session.WriteLine(fmt.Sprintf("    %s = %s", color("cyan", n), aliases[n]))
+		}
+		return
+	}
+	name := strings.ToLower(args[1])
+	if len(args) < 3 {
+		if exp, ok := aliases[name]; ok {
+			usr.session.WriteLine(fmt.Sprintf("%s = %s", color("cyan", name), exp))
+		} else {
+			usr.session.WriteLine(color("magenta", fmt.Sprintf("You have no alias called '%s'.", name)))
+		}
+		return
+	}
+	if c := w.exactCommand(name, levelAdmin); c != nil && (c.name == "alias" || c.name == "unalias") || strings.ContainsAny(name, "!;") {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("'%s' can't be used as an alias.", name)))
+		return
+	}
+	if _, ok := aliases[name]; !ok && len(aliases) >= maxAliases {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("You already have %d aliases, unalias one first.", maxAliases)))
+		return
+	}
+	aliases[name] = strings.Join(args[2:], " ")
+	usr.session.WriteLine(fmt.Sprintf("Alias set: %s = %s", color("cyan", name), aliases[name]))
+}
+
+func unaliasCmd(usr *User, args []string, w *World) {
+	if len(args) < 2 || args[1] == "" {
+		usr.session.WriteLine(color("magenta", "Unalias what?"))
+		return
+	}
+	name := strings.ToLower(args[1])
+	if _, ok := usr.char.aliases[name]; !ok {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("You have no alias called '%s'.", name)))
+		return
+	}
+	delete(usr.char.aliases, name)
+	usr.session.WriteLine(fmt.Sprintf("Alias %s removed.", color("cyan", name)))
+}
+
+func executeCmd(cmd string, usr *User, w *World) {
+	args := strings.Split(cmd, " ")
+	if args[0] == "" {
+		return
+	}
+	c := w.findCommand(args[0], usr.level())
+	if c == nil {
+		usr.session.WriteLine(color("magenta", "Huh?"))
+		return
+	}
+	// handlers see the full command name whatever was typed
+	args[0] = c.name
+	c.handler(usr, args, w)
+}
+
+func emoteCmd(usr *User, args []string, w *World) {
+	emoteHandler(args, usr, w)
+}
+
+// how a command is typed, with its aliases
+func (c *Command) usage() string {
+	u := strings.Join(append([]string{c.name}, c.aliases...), ", ")
+	if c.args != "" {
+		u += " " + c.args
+	}
+	return u
+}
+
+func helpCmd(usr *User, args []string, w *World) {
+	if len(args) > 1 && args[1] != "" {
+		c := w.findCommand(args[1], usr.level())
+		if c == nil {
+			usr.session.WriteLine(color("magenta", fmt.Sprintf("There is no help on '%s'.", args[1])))
+			return
+		}
+		usr.session.WriteLine(color("red", c.usage()))
+		if c.emote {
+			usr.session.WriteLine("    An emote, target someone in the room or leave it off.")
+			return
+		}
+		usr.session.WriteLine("    " + c.desc)
+		if c.minAbbr > 0 && c.minAbbr < len(c.name) {
+			usr.session.WriteLine(fmt.Sprintf("    Can be shortened to '%s'.", c.name[:c.minAbbr]))
+		}
+		return
+	}
+	for _, c := range w.cmnds {
+		if c.emote || c.level > usr.level() {
+			continue
+		}
+		desc := c.desc
+		if c.level >= levelAdmin {
+			desc = "Admin only. " + desc
+		}
+		usr.session.WriteLine(color("red", c.usage()) + " - " + desc)
+	}
+	usr.session.WriteLine("Type 'emotes' for the list of emotes.")
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+// what players type and what it has to run
+func TestCommandAbbreviations(t *testing.T) {
+	cfg = defaultConfig()
+	w := &World{emotes: []*Emote{{name: "flail"}, {name: "smile"}}}
+	if err := w.registerCommands(); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"s":    "south",
+		"sa":   "say",
+		"sav":  "save",
+		"sc":   "score",
+		"sca":  "scan",
+		"sea":  "search",
+		"w":    "west",
+		"we":   "west",
+		"wea":  "wear",
+		"so":   "south",
+		"sw":   "southwest",
+		"fl":   "flee",
+		"fla":  "flail",
+		"sm":   "smile",
+		"i":    "inventory",
+		"l":    "look",
+		"stat": "score",
+	}
+	for typed, want := range cases {
+		c := w.findCommand(typed, levelAdmin)
+		if c == nil || c.name != want {
+			got := "nothing"
+			if c != nil {
+				got = c.name
+			}
+			t.Errorf("%q runs %s, want %s", typed, got, want)
+		}
+	}
+}
+
+// a short form in the table that runs some other command stops the server starting
+func TestRegisterCommandsCatchesClashes(t *testing.T) {
+	w := &World{}
+	w.cmnds = builtinCommands()
+	for _, c := range w.cmnds {
+		if c.name == "say" {
+			c.minAbbr = 1
+		}
+	}
+	if err := w.checkAbbreviations(); err == nil {
+		t.Error("say shortened to 's' wasn't caught")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultConfigPath string = "config.json"
+
+var cfg *Config
+
+// server settings, read from the config file and then overridden by MUD_* environment
+// variables and finally command line flags
+type Config struct {
+	Name            string   `json:"name"`
+	Address         string   `json:"address"`
+	Port            int      `json:"port"`
+	MOTD            string   `json:"motd"`
+	StartRoom       int      `json:"startRoom"`
+	YellDistance    int      `json:"yellDistance"`
+	NameMin         int      `json:"nameMin"`
+	NameMax         int      `json:"nameMax"`
+	IdleMinutes     int      `json:"idleMinutes"`
+	AutosaveMinutes int      `json:"autosaveMinutes"`
+	WorldDir        string   `json:"worldDir"`
+	PlayerDir       string   `json:"playerDir"`
+	StateFile       string   `json:"stateFile"`
+	Admins          []string `json:"admins"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Name:            "Ark's Chatrooms",
+		Port:            8080,
+		StartRoom:       1,
+		YellDistance:    4,
+		NameMin:         3,
+		NameMax:         15,
+		IdleMinutes:     30,
+		AutosaveMinutes: 5,
+		WorldDir:        "data/world",
+		PlayerDir:       "data/players",
+		StateFile:       "data/state.json",
+		Admins:          []string{},
+	}
+}
+
+// one overridable setting, its flag is name and its environment variable MUD_NAME
+type setting struct {
+	name  string
+	usage string
+	str   *string
+	num   *int
+	list  *[]string
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{name: "name", usage: "server name shown at login", str: &c.Name},
+		{name: "address", usage: "address to listen on, blank for all", str: &c.Address},
+		{name: "port", usage: "port to listen on", num: &c.Port},
+		{name: "motd", usage: "message of the day shown after login", str: &c.MOTD},
+		{name: "start-room", usage: "room id new and dead characters start in", num: &c.StartRoom},
+		{name: "yell-distance", usage: "how many rooms away a yell is heard", num: &c.YellDistance},
+		{name: "name-min", usage: "shortest allowed character name", num: &c.NameMin},
+		{name: "name-max", usage: "longest allowed character name", num: &c.NameMax},
+		{name: "idle-minutes", usage: "minutes without input before a player is disconnected, 0 to never", num: &c.IdleMinutes},
+		{name: "autosave-minutes", usage: "minutes between saves of every online player", num: &c.AutosaveMinutes},
+		{name: "world-dir", usage: "directory world files are loaded from", str: &c.WorldDir},
+		{name: "player-dir", usage: "directory player files are saved in", str: &c.PlayerDir},
+		{name: "state-file", usage: "file items left lying around are saved to on shutdown", str: &c.StateFile},
+		{name: "admins", usage: "comma separated names of characters made admins when they next log in, new characters can't take them", list: &c.Admins},
+	}
+}
+
+func (s setting) env() string {
+	return "MUD_" + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+func (s setting) set(v string) error {
+	if s.str != nil {
+		*s.str = v
+		return nil
+	}
+	if s.list != nil {
+		*s.list = []string{}
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				*s.list = append(*s.list, f)
+			}
+		}
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("%q is not a number", v)
+	}
+	*s.num = n
+	return nil
+}
+
+// builds the config from defaults, the config file, the environment and args, in that order
+func loadConfig(args []string) (*Config, error) {
+	c := defaultConfig()
+	fs := flag.NewFlagSet("mudserver", flag.ContinueOnError)
+	path := fs.String("config", defaultConfigPath, "config file to read")
+	// flags are remembered and applied last so they beat both the file and the environment
+	flags := map[string]string{}
+	for _, s := range c.settings() {
+		name := s.name
+		fs.Func(name, s.usage, func(v string) error {
+			flags[name] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(*path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && *path == defaultConfigPath:
+		// running without a config file is fine, the defaults cover everything
+	case err != nil:
+		return nil, err
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, fmt.Errorf("%s: %v", *path, err)
+		}
+	}
+
+	for _, s := range c.settings() {
+		if v, ok := os.LookupEnv(s.env()); ok {
+			if err := s.set(v); err != nil {
+				return nil, fmt.Errorf("%s: %v", s.env(), err)
+			}
+		}
+	}
+	for _, s := range c.settings() {
+		if v, ok := flags[s.name]; ok {
+			if err := s.set(v); err != nil {
+				return nil, fmt.Errorf("-%s: %v", s.name, err)
+			}
+		}
+	}
+	return c, c.validate()
+}
+
+// catches settings that can't work before anything is started, the start room is checked once the world is loaded
+func (c *Config) validate() error {
+	problems := []string{}
+	if c.Name == "" {
+		problems = append(problems, "name can't be blank")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d is not between 1 and 65535", c.Port))
+	}
+	if c.YellDistance < 1 {
+		problems = append(problems, "yell distance must be at least 1")
+	}
+	if c.NameMin < 2 {
+		problems = append(problems, "names must be allowed at least 2 letters")
+	}
+	if c.NameMax < c.NameMin {
+		problems = append(problems, fmt.Sprintf("longest name (%d) is shorter than shortest name (%d)", c.NameMax, c.NameMin))
+	}
+	if c.IdleMinutes < 0 {
+		problems = append(problems, "idle minutes can't be negative")
+	}
+	if c.AutosaveMinutes < 1 {
+		problems = append(problems, "autosave minutes must be at least 1")
+	}
+	if fi, err := os.Stat(c.WorldDir); err != nil || !fi.IsDir() {
+		problems = append(problems, fmt.Sprintf("world directory %q does not exist", c.WorldDir))
+	}
+	if c.PlayerDir == "" {
+		problems = append(problems, "player directory can't be blank")
+	} else if fi, err := os.Stat(c.PlayerDir); err == nil && !fi.IsDir() {
+		problems = append(problems, fmt.Sprintf("player directory %q is not a directory", c.PlayerDir))
+	}
+	if c.StateFile == "" {
+		problems = append(problems, "state file can't be blank")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("bad config:\n    %s", strings.Join(problems, "\n    "))
+	}
+	return nil
+}
+
+func (c *Config) isAdmin(name string) bool {
+	for _, a := range c.Admins {
+		if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) listenAddr() string {
+	return fmt.Sprintf("%s:%d", c.Address, c.Port)
+}
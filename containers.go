@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// what makes an item able to hold others. limits of 0 mean there isn't one
+type Storage struct {
+	capacity  int
+	maxWeight int
+	Closure
+}
+
+func (i *Item) isContainer() bool {
+	return i.store != nil
+}
+
+// the item's own weight plus everything inside it
+func (i *Item) totalWeight() int {
+	wt := i.weight
+	for _, c := range i.contents {
+		wt += c.totalWeight()
+	}
+	return wt
+}
+
+// true if other is i or is somewhere inside it
+func (i *Item) holds(other *Item) bool {
+	if i == other {
+		return true
+	}
+	for _, c := range i.contents {
+		if c.holds(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// why item can't go into container right now, "" if it can
+func (container *Item) refuses(item *Item) string {
+	s := container.store
+	switch {
+	case s == nil:
+		return fmt.Sprintf("%s can't hold anything.", capFirst(container.short))
+	case s.closed:
+		return fmt.Sprintf("%s is closed.", capFirst(container.short))
+	case item.holds(container):
+		return fmt.Sprintf("You can't put %s inside itself.", item.short)
+	case s.capacity > 0 && len(container.contents) >= s.capacity:
+		return fmt.Sprintf("%s is full.", capFirst(container.short))
+	case s.maxWeight > 0 && container.totalWeight()-container.weight+item.totalWeight() > s.maxWeight:
+		return fmt.Sprintf("%s won't fit in %s.", capFirst(item.short), container.short)
+	}
+	return ""
+}
+
+// takes an item out of wherever it is: a room, a player's inventory or equipment, or another item
+func (i *Item) detach() {
+	switch l := i.loc.(type) {
+	case *Room:
+		l.remove(i)
+	case *User:
+		if l.char.eq[i.slot] == i {
+			delete(l.char.eq, i.slot)
+			l.char.applyEffects(i.eff, -1)
+		} else {
+			l.char.inv = removeItemFromSlice(i, l.char.inv)
+		}
+	case *Item:
+		l.remove(i)
+	}
+	i.loc = nil
+}
+
+// where an item is, following containers out to the room or player holding them
+func locPath(loc Location) string {
+	if loc == nil {
+		return "nil"
+	}
+	if i, ok := loc.(*Item); ok {
+		return i.short + " in " + locPath(i.loc)
+	}
+	return loc.getName()
+}
+
+// the room or player ultimately holding loc, looking past any containers
+func outermost(loc Location) Location {
+	for {
+		i, ok := loc.(*Item)
+		if !ok || i.loc == nil {
+			return loc
+		}
+		loc = i.loc
+	}
+}
+
+// splits args around the last word that is one of seps, so 'put cap in bag' gives 'cap' and 'bag'.
+// with no separator the last word is taken as the second part and ok is false
+func splitAround(args []string, seps ...string) (string, string, bool) {
+	for n := len(args) - 2; n > 0; n-- {
+		for _, s := range seps {
+			if strings.EqualFold(args[n], s) {
+				return strings.Join(args[:n], " "), strings.Join(args[n+1:], " "), true
+			}
+		}
+	}
+	if len(args) < 2 {
+		return strings.Join(args, " "), "", false
+	}
+	return strings.Join(args[:len(args)-1], " "), args[len(args)-1], false
+}
+
+// the one container arg refers to, carried or on the floor. tells the user and returns nil if there isn't one
+func (usr *User) findContainer(arg string, w *World) *Item {
+	if parseTarget(arg).all {
+		usr.session.WriteLine(color("magenta", "You can only use one container at a time."))
+		return nil
+	}
+	items := usr.resolveItems(arg, scopeInv|scopeFloor, w)
+	if len(items) == 0 {
+		usr.session.WriteLine(color("magenta", "You don't see that here. "+arg))
+		return nil
+	}
+	if !items[0].isContainer() {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("%s can't hold anything.", capFirst(items[0].short))))
+		return nil
+	}
+	return items[0]
+}
+
+// put <item> in <container>
+func putCmd(usr *User, args []string, w *World) {
+	what, into, _ := splitAround(args[1:], "in", "into")
+	if what == "" || into == "" {
+		usr.session.WriteLine(color("magenta", "Put what in what?"))
+		return
+	}
+	container := usr.findContainer(into, w)
+	if container == nil {
+		return
+	}
+	items := usr.resolveItems(what, scopeInv, w)
+	if len(items) == 0 {
+		usr.session.WriteLine(color("magenta", "You are not carrying that. "+what))
+		return
+	}
+	for _, i := range items {
+		if i == container && len(items) > 1 {
+			continue
+		}
+		if msg := container.refuses(i); msg != "" {
+			usr.session.WriteLine(color("magenta", msg))
+			return
+		}
+		i.detach()
+		container.add(i)
+		i.loc = container.getLocation()
+		usr.session.WriteLine(fmt.Sprintf("You put %s in %s.", color("cyan", i.short), color("cyan", container.short)))
+		usr.room.sendAll(fmt.Sprintf("%s puts %s in %s.", usr.name, color("cyan", i.short), color("cyan", container.short)), usr.char)
+	}
+}
+
+// take <item> from <container>, called by take when it's given a container
+func takeFromCmd(usr *User, what, from string, w *World) {
+	container := usr.findContainer(from, w)
+	if container == nil {
+		return
+	}
+	if container.store.closed {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("%s is closed.", capFirst(container.short))))
+		return
+	}
+	items := resolveIn(what, container.contents)
+	if len(items) == 0 {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("There's nothing like that in %s.", container.short)))
+		return
+	}
+	carrying := outermost(container) == usr.getLocation()
+	for _, i := range items {
+		if !carrying && !usr.char.canCarry(i) {
+			usr.session.WriteLine(color("magenta", fmt.Sprintf("%s is too heavy for you to carry.", capFirst(i.short))))
+			return
+		}
+		i.detach()
+		usr.char.inv = append(usr.char.inv, i)
+		i.loc = usr.getLocation()
+		usr.session.WriteLine(fmt.Sprintf("You take %s from %s.", color("cyan", i.short), color("cyan", container.short)))
+		usr.room.sendAll(fmt.Sprintf("%s takes %s from %s.", usr.name, color("cyan", i.short), color("cyan", container.short)), usr.char)
+	}
+}
+
+// look in <container>
+func lookInCmd(usr *User, arg string, w *World) {
+	container := usr.findContainer(arg, w)
+	if container == nil {
+		return
+	}
+	if container.store.closed {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("%s is closed.", capFirst(container.short))))
+		return
+	}
+	usr.session.WriteLine(fmt.Sprintf("You look inside %s.", color("cyan", container.short)))
+	if len(container.contents) == 0 {
+		usr.session.WriteLine("    It's empty.")
+		return
+	}
+	order, counts := returnItemCountMap(container.contents, false)
+	for _, itm := range order {
+		if cnt := counts[itm]; cnt > 1 {
+			usr.session.WriteLine(color("cyan", "    "+itm) + " (" + color("red", fmt.Sprint(cnt)) + ")")
+		} else {
+			usr.session.WriteLine(color("cyan", "    "+itm))
+		}
+	}
+}
+
+// true if c is carrying or wearing an item with the given prototype id
+func (c *Character) hasKey(id int) bool {
+	for _, i := range c.inv {
+		if i.id == id {
+			return true
+		}
+	}
+	for _, i := range c.eq {
+		if i.id == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+// what someone logging out carries goes from the world, down to whatever's inside their bags
+func TestRemoveItemTree(t *testing.T) {
+	items := map[string]map[int]*Item{}
+	for _, name := range []string{"a bag", "a pouch", "a gem"} {
+		addItem(items, &Item{name: name})
+	}
+	gem := &Item{name: "a gem"}
+	pouch := &Item{name: "a pouch", contents: []*Item{gem}}
+	bag := &Item{name: "a bag", contents: []*Item{pouch}}
+	other := &Item{name: "a gem"}
+	for _, i := range []*Item{bag, pouch, gem, other} {
+		addItem(items, i)
+	}
+
+	removeItemTree(items, bag)
+	for name, m := range items {
+		want := 1
+		if name == "a gem" {
+			want = 2
+		}
+		if len(m) != want {
+			t.Errorf("%d of %s left, want %d", len(m), name, want)
+		}
+	}
+	if items["a gem"][1] != other {
+		t.Error("removed the wrong gem")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a direction exits can be named by. exits can have any keyword, but directions come with a short
+// form players can type, an opposite, and their own way of being talked about
+type Direction struct {
+	name string
+	abbr string
+	opp  string
+	// where someone going this way is seen arriving from, and where an exit this way lies.
+	// left empty they're 'from the <opp>' and 'to the <name>'
+	from string
+	to   string
+	// has a command of its own rather than going through go
+	cmd bool
+	// what someone walking into nothing this way gets, and what the room sees, %s being their name
+	bump     string
+	bumpRoom string
+}
+
+const (
+	wallBump     = "You slam your face into an invisble wall. Ouch!"
+	wallBumpRoom = "%s slams their face into an invisible wall to the %s."
+)
+
+// the table movement, look and the prompt go by. order is the order exits are talked about in
+var directions = []*Direction{
+	{name: "north", abbr: "n", opp: "south", cmd: true},
+	{name: "northeast", abbr: "ne", opp: "southwest", cmd: true},
+	{name: "east", abbr: "e", opp: "west", cmd: true},
+	{name: "southeast", abbr: "se", opp: "northwest", cmd: true},
+	{name: "south", abbr: "s", opp: "north", cmd: true},
+	{name: "southwest", abbr: "sw", opp: "northeast", cmd: true},
+	{name: "west", abbr: "w", opp: "east", cmd: true},
+	{name: "northwest", abbr: "nw", opp: "southeast", cmd: true},
+	{name: "up", abbr: "u", opp: "down", from: "from below", to: "above", cmd: true,
+		bump: "What, is there a staircase thats invisible here?", bumpRoom: "%s climbs an invisible staircase and falls flat on their face."},
+	{name: "down", abbr: "d", opp: "up", from: "from above", to: "below", cmd: true,
+		bump: "In what ground orifice do you plan to stuff your body?", bumpRoom: "%s decends an imaginary staircase. Are we miming?"},
+	{name: "in", abbr: "i", opp: "out", from: "from outside", to: "inside",
+		bump: "You can't do that Jim.", bumpRoom: "%s makes motions as if they're trying to crawl in or out of something..."},
+	{name: "out", abbr: "o", opp: "in", from: "from inside", to: "outside",
+		bump: "You can't do that Jim.", bumpRoom: "%s makes motions as if they're trying to crawl in or out of something..."},
+	{name: "through", abbr: "t", opp: "through", from: "from the other side", to: "on the other side",
+		bump: "You successfully move through the air, or was that not your goal?", bumpRoom: "%s successfully penetrates the air. You clap."},
+}
+
+// the direction word is the name or short form of, nil if it isn't one
+func findDir(word string) *Direction {
+	word = strings.ToLower(strings.TrimSpace(word))
+	for _, d := range directions {
+		if word == d.name || word == d.abbr {
+			return d
+		}
+	}
+	return nil
+}
+
+// the exit keyword a player means by word, short forms of directions spelled out
+func exitWord(word string) string {
+	if d := findDir(word); d != nil {
+		return d.name
+	}
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+// 'from the south' for someone who went north. other keywords don't say where someone came from
+func arrivalFrom(keyword string) string {
+	d := findDir(keyword)
+	switch {
+	case d == nil:
+		return ""
+	case d.from != "":
+		return d.from
+	}
+	return "from the " + d.opp
+}
+
+// where a sound seems to come from to someone hearing it through their exit called keyword,
+// 'from the east' for the east exit
+func soundFrom(keyword string) string {
+	if d := findDir(keyword); d != nil {
+		return arrivalFrom(d.opp)
+	}
+	return "from beyond the " + keyword
+}
+
+// 'to the north' for an exit going north, or 'through the hatch' for one that isn't a direction
+func exitPlace(keyword string) string {
+	d := findDir(keyword)
+	switch {
+	case d == nil:
+		return "through the " + keyword
+	case d.to != "":
+		return d.to
+	}
+	return "to the " + d.name
+}
+
+// how going through an exit is put, 'north' or 'through the hatch'
+func heading(keyword string) string {
+	if findDir(keyword) != nil {
+		return keyword
+	}
+	return "through the " + keyword
+}
+
+// the exit in r called word that u knows about, word may be a short form
+func (r *Room) exitFor(u *User, word string) *Exit {
+	if ex := r.getExit(exitWord(word)); ex != nil && u.sees(ex) {
+		return ex
+	}
+	return nil
+}
+
+// the exits u can see, directions in table order and then the rest as they're listed
+func (r *Room) visibleExits(u *User) []*Exit {
+	exits := []*Exit{}
+	for _, d := range directions {
+		if ex := r.getExit(d.name); ex != nil && u.sees(ex) {
+			exits = append(exits, ex)
+		}
+	}
+	for _, ex := range r.exits {
+		if findDir(ex.keyword) == nil && u.sees(ex) {
+			exits = append(exits, ex)
+		}
+	}
+	return exits
+}
+
+// the exits u can see for the prompt, directions by their short form
+func (r *Room) exitLetters(u *User) string {
+	parts := []string{}
+	for _, ex := range r.visibleExits(u) {
+		if d := findDir(ex.keyword); d != nil {
+			parts = append(parts, strings.ToUpper(d.abbr))
+		} else {
+			parts = append(parts, ex.keyword)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tells u, and whoever's watching, that there's no exit called word here
+func bumpInto(u *User, word string) {
+	d := findDir(word)
+	if d == nil {
+		u.session.WriteLine(color("magenta", fmt.Sprintf("You can't go '%s'.", word)))
+		return
+	}
+	bump, bumpRoom := d.bump, d.bumpRoom
+	if bump == "" {
+		bump, bumpRoom = wallBump, fmt.Sprintf(wallBumpRoom, "%s", d.name)
+	}
+	u.session.WriteLine(color("magenta", bump))
+	u.room.sendAll(color("green", fmt.Sprintf(bumpRoom, u.name)), u.char)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// base chance out of 100 of picking a lock, plus 5 for each point of dex bonus
+const pickChance int = 40
+
+// anything that can be opened and closed, and locked if it has a key: containers and doors
+type Closure struct {
+	closable bool
+	closed   bool
+	locked   bool
+	// prototype id of the item that locks and unlocks it, 0 if there's no lock
+	key int
+	// the lock can't be picked
+	noPick bool
+}
+
+// a door on an exit. both sides of a passage share the same door, so they can't disagree
+type Door struct {
+	name string
+	Closure
+}
+
+// opens, closes, locks or unlocks cl for c, returns why it couldn't or "" once it's done
+func (cl *Closure) change(verb string, c *Character) string {
+	if !cl.closable {
+		return "It can't be opened or closed."
+	}
+	switch verb {
+	case "open":
+		switch {
+		case !cl.closed:
+			return "It's already open."
+		case cl.locked:
+			return "It's locked."
+		}
+		cl.closed = false
+	case "close":
+		if cl.closed {
+			return "It's already closed."
+		}
+		cl.closed = true
+	case "lock", "unlock":
+		switch {
+		case cl.key == 0:
+			return "It doesn't have a lock."
+		case !cl.closed:
+			return "You'll have to close it first."
+		case cl.locked == (verb == "lock"):
+			return fmt.Sprintf("It's already %sed.", verb)
+		case !c.hasKey(cl.key):
+			return "You don't have the key."
+		}
+		cl.locked = verb == "lock"
+	case "pick":
+		switch {
+		case cl.key == 0:
+			return "It doesn't have a lock."
+		case !cl.locked:
+			return "It isn't locked."
+		case cl.noPick:
+			return "The lock is too well made to pick."
+		case rand.Intn(100) >= pickChance+statBonus(c.stats().dex)*5:
+			return "You fail to pick the lock."
+		}
+		cl.locked = false
+	}
+	return ""
+}
+
+// open, closed or locked, for saving
+func (cl *Closure) state() string {
+	switch {
+	case cl.locked:
+		return "locked"
+	case cl.closed:
+		return "closed"
+	}
+	return "open"
+}
+
+func (cl *Closure) setState(state string) {
+	cl.closed = state == "closed" || state == "locked"
+	cl.locked = state == "locked" && cl.key != 0
+}
+
+// the exit called dir with a door on it, short forms of directions work too
+func (r *Room) doorByDir(dir string) *Exit {
+	if ex := r.getExit(exitWord(dir)); ex != nil && ex.door != nil {
+		return ex
+	}
+	return nil
+}
+
+// 'the front door to the west', or just 'the hatch' when the exit is named after its door
+func (d *Door) where(ex *Exit) string {
+	if d.name == ex.keyword {
+		return "the " + d.name
+	}
+	return fmt.Sprintf("the %s %s", d.name, exitPlace(ex.keyword))
+}
+
+// the first door in r going by name, 'gate' or 'front door' say
+func (r *Room) doorByName(name string) *Exit {
+	t := parseTarget(name)
+	if t.all || len(t.words) == 0 {
+		return nil
+	}
+	for _, ex := range r.exits {
+		if ex.door != nil && t.matches(nameKeywords(ex.door.name)) {
+			return ex
+		}
+	}
+	return nil
+}
+
+// the exit in the room ex leads to that leads back, nil for a one way passage
+func (w *World) reverseExit(r *Room, ex *Exit) (*Room, *Exit) {
+	to := getRoomByID(ex.linkedID, w)
+	if to == nil {
+		return nil, nil
+	}
+	for _, back := range to.exits {
+		if back.linkedID == r.id {
+			return to, back
+		}
+	}
+	return to, nil
+}
+
+// false if a closed door is in the way
+func (ex *Exit) isOpen() bool {
+	return ex.door == nil || !ex.door.closed
+}
+
+// open, close, lock, unlock and pick, for doors and containers
+func openCmd(usr *User, args []string, w *World) {
+	verb := args[0]
+	if len(args) < 2 || args[1] == "" {
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("What are you trying to %s?", verb)))
+		return
+	}
+	arg := strings.ToLower(strings.Join(args[1:], " "))
+	// a direction always means a door, a name only does if nothing carried or on the floor goes by it
+	ex := usr.room.doorByDir(arg)
+	if ex == nil && len(usr.resolveItems(arg, scopeInv|scopeFloor, w)) == 0 {
+		ex = usr.room.doorByName(arg)
+	}
+	if ex != nil && usr.sees(ex) {
+		doorCmd(usr, verb, ex, w)
+		return
+	}
+	container := usr.findContainer(arg, w)
+	if container == nil {
+		return
+	}
+	if msg := container.store.change(verb, usr.char); msg != "" {
+		if !container.store.closable {
+			msg = fmt.Sprintf("%s can't be opened or closed.", capFirst(container.short))
+		}
+		usr.session.WriteLine(color("magenta", msg))
+		return
+	}
+	what := lockWords(verb, color("cyan", container.short))
+	usr.session.WriteLine(fmt.Sprintf("You %s %s.", verb, what))
+	usr.room.sendAll(fmt.Sprintf("%s %ss %s.", usr.name, verb, what), usr.char)
+}
+
+// what a verb acts on, picking is done to the lock rather than the thing itself
+func lockWords(verb, what string) string {
+	if verb == "pick" {
+		return "the lock on " + what
+	}
+	return what
+}
+
+func doorCmd(usr *User, verb string, ex *Exit, w *World) {
+	d := ex.door
+	if msg := d.change(verb, usr.char); msg != "" {
+		usr.session.WriteLine(color("magenta", msg))
+		return
+	}
+	what := lockWords(verb, d.where(ex))
+	usr.session.WriteLine(fmt.Sprintf("You %s %s.", verb, what))
+	usr.room.sendAll(fmt.Sprintf("%s %ss %s.", usr.name, verb, what), usr.char)
+	// the other side hears it
+	if to, back := w.reverseExit(usr.room, ex); to != nil && back != nil {
+		switch verb {
+		case "open", "close":
+			to.sendAll(fmt.Sprintf("%s %ss.", capFirst(d.where(back)), verb))
+		default:
+			to.sendAll(fmt.Sprintf("You hear a click from %s.", d.where(back)))
+		}
+	}
+}
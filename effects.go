@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// adds an item's effects to what c is wearing, or takes them away again when sign is -1
+func (c *Character) applyEffects(e *Effects, sign int) {
+	c.mods.add(e, sign)
+	c.clampPools()
+}
+
+// exp isn't a stat, it's never added
+func (m *Effects) add(e *Effects, sign int) {
+	if e == nil {
+		return
+	}
+	m.str += sign * e.str
+	m.dex += sign * e.dex
+	m.con += sign * e.con
+	m.intl += sign * e.intl
+	m.wis += sign * e.wis
+	m.cha += sign * e.cha
+	m.fort += sign * e.fort
+	m.ref += sign * e.ref
+	m.wil += sign * e.wil
+	m.att += sign * e.att
+	m.dam += sign * e.dam
+	m.hp += sign * e.hp
+	m.mana += sign * e.mana
+	m.moves += sign * e.moves
+}
+
+// rebuilds c's modifiers from scratch out of whatever they have equipped
+func (c *Character) recalcEffects() {
+	c.mods = Effects{}
+	for _, i := range c.eq {
+		c.mods.add(i.eff, 1)
+	}
+	c.clampPools()
+}
+
+// keeps hp, mana and moves from sitting above maximums that just dropped
+func (c *Character) clampPools() {
+	s := c.stats()
+	if c.hp > s.hp {
+		c.hp = s.hp
+	}
+	if c.mana > s.mana {
+		c.mana = s.mana
+	}
+	if c.moves > s.moves {
+		c.moves = s.moves
+	}
+}
+
+// c's own stats, hp, mana and moves being the maximums
+func (c *Character) baseStats() Effects {
+	return Effects{
+		str: c.str, dex: c.dex, con: c.con, intl: c.intl, wis: c.wis, cha: c.cha,
+		fort: c.fort, ref: c.ref, wil: c.wil, att: c.att, dam: c.dam,
+		hp: c.maxHp, mana: c.maxMana, moves: c.maxMoves,
+	}
+}
+
+// c's stats with everything they're wearing counted
+func (c *Character) stats() Effects {
+	b, m := c.baseStats(), c.mods
+	return Effects{
+		str: b.str + m.str, dex: b.dex + m.dex, con: b.con + m.con, intl: b.intl + m.intl, wis: b.wis + m.wis, cha: b.cha + m.cha,
+		fort: b.fort + m.fort, ref: b.ref + m.ref, wil: b.wil + m.wil, att: b.att + m.att, dam: b.dam + m.dam,
+		hp: b.hp + m.hp, mana: b.mana + m.mana, moves: b.moves + m.moves,
+	}
+}
+
+// pairs each stat's name with its value, in the order they're listed
+func (e Effects) list() ([]string, []int) {
+	return []string{"Str", "Dex", "Con", "Int", "Wis", "Cha", "Fort", "Ref", "Wil", "Att", "Dam", "Hp", "Mana", "Moves"},
+		[]int{e.str, e.dex, e.con, e.intl, e.wis, e.cha, e.fort, e.ref, e.wil, e.att, e.dam, e.hp, e.mana, e.moves}
+}
+
+// the non zero effects, like 'str +1, hp +5'
+func (e *Effects) String() string {
+	names, vals := e.list()
+	parts := []string{}
+	for n, v := range vals {
+		if v != 0 {
+			parts = append(parts, fmt.Sprintf("%s %+d", strings.ToLower(names[n]), v))
+		}
+	}
+	if e.exp != 0 {
+		parts = append(parts, fmt.Sprintf("exp %+d", e.exp))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,65 @@
+package main
+
+import "fmt"
+
+// each point of strength lets a character carry this much
+const carryPerStr int = 5
+
+// how weighed down a character is. a tier applies while the weight carried is at most upTo
+// percent of what they can carry, the last tier has no limit
+type encumbrance struct {
+	name     string
+	upTo     int
+	moveCost int
+	penalty  int
+}
+
+var encumbranceTiers = []encumbrance{
+	{"unburdened", 50, 1, 0},
+	{"burdened", 75, 2, 1},
+	{"heavily burdened", 100, 3, 3},
+	// only happens when something lowers strength, taking and giving won't go past 100
+	{"overloaded", 0, 0, 5},
+}
+
+// the most weight c can carry
+func (c *Character) carryCapacity() int {
+	return c.stats().str * carryPerStr
+}
+
+// everything c is carrying and wearing, containers included
+func (c *Character) carried() int {
+	wt := 0
+	for _, i := range c.inv {
+		wt += i.totalWeight()
+	}
+	for _, i := range c.eq {
+		wt += i.totalWeight()
+	}
+	return wt
+}
+
+func (c *Character) encumbrance() encumbrance {
+	cp := c.carryCapacity()
+	for _, e := range encumbranceTiers[:len(encumbranceTiers)-1] {
+		if c.carried()*100 <= e.upTo*cp {
+			return e
+		}
+	}
+	return encumbranceTiers[len(encumbranceTiers)-1]
+}
+
+// true if c can pick up i without going over what they can carry
+func (c *Character) canCarry(i *Item) bool {
+	return c.carried()+i.totalWeight() <= c.carryCapacity()
+}
+
+// the weight line shown under the inventory
+func (c *Character) weightLine() string {
+	e := c.encumbrance()
+	line := fmt.Sprintf("Carrying %d of %d weight, %s.", c.carried(), c.carryCapacity(), e.name)
+	if e.penalty > 0 {
+		return color("yellow", line)
+	}
+	return line
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// base chance out of 100 of a search turning up each hidden exit, plus 5 for each point of wis bonus
+const searchChance int = 50
+
+// true if u knows ex is there, hidden exits have to be found first
+func (u *User) sees(ex *Exit) bool {
+	return !ex.hidden || u.found[ex]
+}
+
+// anything besides a door that decides who can use an exit
+func (ex *Exit) restricted() bool {
+	return ex.hidden || ex.minLevel > 0 || len(ex.classes) > 0 || ex.needs != 0
+}
+
+// why c can't go through ex, "" if they can
+func (c *Character) barredBy(ex *Exit, w *World) string {
+	why := ""
+	switch {
+	case c.level() < ex.minLevel:
+		why = fmt.Sprintf("You must be level %d to go that way.", ex.minLevel)
+	case len(ex.classes) > 0 && !containsFold(ex.classes, c.class):
+		why = fmt.Sprintf("Only a %s may go that way.", strings.Join(ex.classes, " or "))
+	case ex.needs != 0 && !c.hasKey(ex.needs):
+		why = "You need something to go that way."
+		if p := getProtoByID(w.items, ex.needs); p != nil {
+			why = fmt.Sprintf("You need %s to go that way.", p.short)
+		}
+	}
+	if why != "" && ex.refuse != "" {
+		return ex.refuse
+	}
+	return why
+}
+
+// true if any exit in r goes to other
+func (r *Room) leadsTo(other *Room) bool {
+	for _, ex := range r.exits {
+		if ex.linkedID == other.id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// search, looks for hidden exits in the room
+func searchCmd(usr *User, args []string, w *World) {
+	usr.room.sendAll(fmt.Sprintf("%s searches the area.", color("cyan", usr.name)), usr.char)
+	found := false
+	for _, ex := range usr.room.exits {
+		if usr.sees(ex) || rand.Intn(100) >= searchChance+statBonus(usr.char.stats().wis)*5 {
+			continue
+		}
+		if usr.found == nil {
+			usr.found = map[*Exit]bool{}
+		}
+		usr.found[ex] = true
+		found = true
+		usr.session.WriteLine(color("green", fmt.Sprintf("You find a hidden way %s!", heading(ex.keyword))))
+	}
+	if !found {
+		usr.session.WriteLine("You search around but find nothing.")
+	}
+}
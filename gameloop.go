@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	pulsesPerSecond int = 10
+	inputQueueSize  int = 256
+)
+
+// the game loop is the only goroutine that reads or changes world state. connection goroutines
+// send it events through inputChannel and it answers by queueing output on their sessions
+func startGameLoop(inputChannel <-chan ClientInput, world *World) {
+	ticker := time.NewTicker(time.Second / time.Duration(pulsesPerSecond))
+	defer ticker.Stop()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	pulse := 0
+	for {
+		select {
+		case input := <-inputChannel:
+			handleInput(input)
+			world.flushPrompts()
+		case <-ticker.C:
+			pulse++
+			world.pulse(pulse)
+			if world.checkShutdown() {
+				return
+			}
+			world.flushPrompts()
+		case sig := <-sigs:
+			log.Printf("Got %v, shutting down", sig)
+			for _, u := range world.users {
+				u.send(color("red", "The server is shutting down now."))
+			}
+			world.stop(false)
+			return
+		}
+	}
+}
+
+// timed world updates, each runs every so many pulses
+func (w *World) pulse(n int) {
+	if n%(combatRoundSeconds*pulsesPerSecond) == 0 {
+		combatRound(w)
+	}
+	if n%(mobileTickSeconds*pulsesPerSecond) == 0 {
+		mobileTick(w)
+	}
+	if n%(roomResetMinutes*60*pulsesPerSecond) == 0 {
+		w.resetRooms()
+	}
+	if n%(cfg.AutosaveMinutes*60*pulsesPerSecond) == 0 {
+		saveAllUsers(w)
+	}
+}
+
+func handleInput(input ClientInput) {
+	switch event := input.event.(type) {
+	case *InputEvent:
+		if !input.world.hasUser(input.user) {
+			return
+		}
+		fmt.Printf("%s: \"%s\"\r\n", input.user.name, event.msg)
+		// pressing enter already moved them past a compact prompt
+		input.user.promptOpen = false
+		input.user.runLine(event.msg, input.world)
+
+	case *UserJoinedEvent:
+		if isOnline(input.user.name, input.world) {
+			input.user.session.WriteLine(fmt.Sprintf("%s is already playing.", input.user.name))
+			input.user.session.Close()
+			return
+		}
+		fmt.Println("User Joined:", input.user.name)
+		// characters named in the admins setting are made admins for good when they log in
+		input.user.admin = event.pf.Admin || cfg.isAdmin(input.user.name)
+		input.user.char = event.pf.Char.toCharacter(input.user, input.world)
+		input.user.room = getRoomByID(event.pf.Char.Room, input.world)
+		if input.user.room == nil {
+			input.user.room = getRoomByID(cfg.StartRoom, input.world)
+		}
+		input.world.addUser(input.user)
+		input.user.room.addUser(input.user)
+		if event.rebooted {
+			input.user.session.WriteLine(color("red", "Reboot complete."))
+			break
+		}
+		input.user.session.WriteLine(fmt.Sprintf("Welcome %s. Type help for a list of commands.", color("cyan", input.user.name)))
+		if cfg.MOTD != "" {
+			input.user.session.WriteLine(color("yellow", strings.ReplaceAll(cfg.MOTD, "\n", "\r\n")))
+		}
+		input.user.room.sendText(input.user)
+		for _, user := range input.world.users {
+			if user != input.user {
+				user.send(color("red", fmt.Sprintf("%s has joined!", input.user.name)))
+			}
+		}
+		// new characters, and any that left before finishing, pick a class before anything else
+		if input.user.char.class == "" && len(input.world.classes) > 0 {
+			input.user.pushHandler(creationPrompt(input.world))
+		}
+
+	case *UserLeftEvent:
+		// a connection turned away at login never made it into the world
+		if !input.world.hasUser(input.user) {
+			return
+		}
+		un := input.user.name
+		fmt.Println("User Left:", un)
+		input.user.char.stopFighting(input.world)
+		if err := input.user.save(); err != nil {
+			log.Printf("Error saving %s: %v", un, err)
+		}
+		// saved characters rebuild their items on login, so drop these instances, and whatever's in
+		// them, from the world
+		for _, i := range input.user.char.inv {
+			removeItemTree(input.world.items, i)
+		}
+		for _, i := range input.user.char.eq {
+			removeItemTree(input.world.items, i)
+		}
+		input.user.room.removeUser(input.user)
+		input.world.removeUser(input.user)
+		for _, user := range input.world.users {
+			user.send(color("red", fmt.Sprintf("%s has left us!", un)))
+		}
+		return
+	}
+	input.user.promptDue = true
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// these run a real server on a loopback port, each client on its own goroutine talking to it over
+// a socket the way players do, so go test -race sees all the sharing between connection goroutines
+// and the game loop that a live server has
+
+const testTimeout = 10 * time.Second
+
+type testServer struct {
+	world  *World
+	addr   string
+	served chan error
+}
+
+// starts a server on a copy of the world files, with its own player directory and state file
+func startTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	worldDir := filepath.Join(dir, "world")
+	if err := os.MkdirAll(worldDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join("data", "world", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(worldDir, filepath.Base(f)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg = defaultConfig()
+	cfg.WorldDir = worldDir
+	cfg.PlayerDir = filepath.Join(dir, "players")
+	cfg.StateFile = filepath.Join(dir, "state.json")
+	cfg.Admins = []string{"Admin", "Boss"}
+	// admin names can't be taken by new characters, so Admin is made beforehand and becomes one on logging in
+	writeTestPlayer(t, "Admin", false)
+
+	world, err := newWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// command handlers reach for the global world, as they do when startServer sets it
+	w = world
+	world.ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputChannel := make(chan ClientInput, inputQueueSize)
+	go startGameLoop(inputChannel, world)
+	ts := &testServer{world: world, addr: world.ln.Addr().String(), served: make(chan error, 1)}
+	go func() { ts.served <- world.serve(inputChannel) }()
+	return ts
+}
+
+// shuts the server down through an admin's shutdown command and waits until it has stopped,
+// after which the world can be looked at from the test
+func (ts *testServer) shutdown(t *testing.T, admin *testClient) {
+	t.Helper()
+	admin.send("shutdown")
+	if err := admin.expect("see you soon"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ts.world.stopped:
+	case <-time.After(testTimeout):
+		t.Fatal("server didn't stop")
+	}
+	select {
+	case err := <-ts.served:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("accept loop didn't return")
+	}
+}
+
+type testClient struct {
+	name string
+	conn net.Conn
+
+	mu   sync.Mutex
+	out  []byte
+	seen int
+	done bool
+}
+
+func dial(addr, name string) (*testClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &testClient{name: name, conn: conn}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			c.mu.Lock()
+			c.out = append(c.out, buf[:n]...)
+			if err != nil {
+				c.done = true
+			}
+			c.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (c *testClient) send(line string) error {
+	_, err := c.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// waits for text to turn up after whatever the last expect matched
+func (c *testClient) expect(text string) error {
+	deadline := time.Now().Add(testTimeout)
+	for {
+		c.mu.Lock()
+		rest, done := string(c.out[c.seen:]), c.done
+		if n := strings.Index(rest, text); n >= 0 {
+			c.seen += n + len(text)
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		if done || time.Now().After(deadline) {
+			return fmt.Errorf("%s never saw %q, got %q", c.name, text, rest)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// lets the next expect look through everything received so far again
+func (c *testClient) rewind() {
+	c.mu.Lock()
+	c.seen = 0
+	c.mu.Unlock()
+}
+
+// sends line and waits for reply
+func (c *testClient) do(line, reply string) error {
+	if err := c.send(line); err != nil {
+		return err
+	}
+	return c.expect(reply)
+}
+
+// makes a new character called name and takes it through character creation
+func newTestPlayer(addr, name string) (*testClient, error) {
+	c, err := dial(addr, name)
+	if err != nil {
+		return nil, err
+	}
+	steps := [][2]string{
+		{"", "What are you called?"},
+		{name, "(y/n)"},
+		{"y", "Choose a password:"},
+		{"secret1", "Type it again:"},
+		{"secret1", "Choose a class"},
+		{"war", "(roll/buy)"},
+		{"buy", "Enter six scores"},
+		{"15 14 13 12 10 8", "You are now"},
+	}
+	for _, s := range steps {
+		if s[0] == "" {
+			err = c.expect(s[1])
+		} else {
+			err = c.do(s[0], s[1])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, c.expect("Exits:")
+}
+
+// saves a character that has been through creation, to log in as
+func writeTestPlayer(t *testing.T, name string, admin bool) {
+	t.Helper()
+	pf := &playerFile{Name: name, Created: time.Now(), Admin: admin}
+	if err := pf.setPassword("secret1"); err != nil {
+		t.Fatal(err)
+	}
+	pf.Char = characterData{Class: "Warrior", Room: cfg.StartRoom}
+	if err := pf.write(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// logs back in as a character made earlier
+func loginTestPlayer(addr, name string) (*testClient, error) {
+	c, err := dial(addr, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.expect("What are you called?"); err != nil {
+		return nil, err
+	}
+	if err := c.do(name, "Password:"); err != nil {
+		return nil, err
+	}
+	return c, c.do("secret1", "Welcome")
+}
+
+var testNames = []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo", "Foxtrot", "Golf", "Hotel"}
+
+// players join, wander back and forth and leave all at once while the pulse keeps running
+func TestConcurrentPlayers(t *testing.T) {
+	ts := startTestServer(t)
+	admin, err := loginTestPlayer(ts.addr, "Admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(testNames))
+	for n, name := range testNames {
+		wg.Add(1)
+		go func(n int, name string) {
+			defer wg.Done()
+			c, err := newTestPlayer(ts.addr, name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for i := 0; i < 5; i++ {
+				if err := c.do("east", "You go east."); err != nil {
+					errs <- err
+					return
+				}
+				if err := c.do("west", "You go west."); err != nil {
+					errs <- err
+					return
+				}
+			}
+			if err := c.do("say hello", "You say"); err != nil {
+				errs <- err
+				return
+			}
+			// half quit, half just drop their connection
+			if n%2 == 0 {
+				if err := c.do("quit", "Farewell"); err != nil {
+					errs <- err
+				}
+			}
+			c.conn.Close()
+		}(n, name)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	// leaving is announced in whatever order the loop gets to it
+	for _, name := range testNames {
+		admin.rewind()
+		if err := admin.expect(name + " has left us!"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ts.shutdown(t, admin)
+	if len(ts.world.users) != 1 || ts.world.users[0].name != "Admin" {
+		names := []string{}
+		for _, u := range ts.world.users {
+			names = append(names, u.name)
+		}
+		t.Errorf("still online after everyone left: %v", names)
+	}
+	if u := ts.world.findUser("admin"); u == nil || u.name != "Admin" {
+		t.Error("Admin can't be found by name")
+	}
+	for _, name := range testNames {
+		if ts.world.findUser(name) != nil {
+			t.Errorf("%s can still be found by name", name)
+		}
+		pf, err := loadPlayerFile(name)
+		if err != nil {
+			t.Errorf("%s wasn't saved: %v", name, err)
+			continue
+		}
+		if pf.Char.Class != "Warrior" {
+			t.Errorf("%s saved with class %q", name, pf.Char.Class)
+		}
+	}
+	for _, r := range ts.world.rooms {
+		for _, u := range r.users {
+			if u.name != "Admin" {
+				t.Errorf("%s left behind in %s", u.name, r.name)
+			}
+		}
+	}
+}
+
+// an admin answers the item creation prompt while other players, who can't use it, come and go around them
+func TestCreateWhilePlayersMove(t *testing.T) {
+	ts := startTestServer(t)
+	admin, err := loginTestPlayer(ts.addr, "Admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for _, name := range testNames[:4] {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			c, err := newTestPlayer(ts.addr, name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer c.conn.Close()
+			if err := c.do("create", "Huh?"); err != nil {
+				errs <- err
+				return
+			}
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err := c.do("east", "You go east."); err != nil {
+					errs <- err
+					return
+				}
+				if err := c.do("west", "You go west."); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(name)
+	}
+
+	answers := [][2]string{
+		{"create", "Name of Item?"},
+		{"a test helm", "Keywords"},
+		{"helm test", "lying on the floor"},
+		{"", "Description when examined?"},
+		{"A tin helm made for testing.", "Equipment slot"},
+		{"head", "Armor class"},
+		{"0", "Damage of item"},
+		{"0", "Flat Damage"},
+		{"0", "Weight of item"},
+		{"2", "Success. Type 'new "},
+	}
+	for _, a := range answers {
+		if err := admin.do(a[0], a[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := admin.do("new helm", "a test helm"); err != nil {
+		t.Error(err)
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// someone coming back finds their character where they left it
+	c, err := loginTestPlayer(ts.addr, testNames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.expect("The Entryway"); err != nil {
+		t.Error(err)
+	}
+
+	ts.shutdown(t, admin)
+	if _, ok := ts.world.items["a test helm"]; !ok {
+		t.Error("created item isn't in the world")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorldDir, createdItemsFile)); err != nil {
+		t.Errorf("created item wasn't saved: %v", err)
+	}
+}
+
+// only characters that already exist can be made admins, and once they are it's saved with them
+func TestAdminNames(t *testing.T) {
+	ts := startTestServer(t)
+	admin, err := loginTestPlayer(ts.addr, "Admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := dial(ts.addr, "Boss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.conn.Close()
+	if err := c.expect("What are you called?"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.do("boss", "That name is reserved."); err != nil {
+		t.Error(err)
+	}
+
+	// named in their file and not in the config
+	writeTestPlayer(t, "Chief", true)
+	chief, err := loginTestPlayer(ts.addr, "Chief")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chief.do("new nothing", "Did not find item"); err != nil {
+		t.Error(err)
+	}
+	player, err := newTestPlayer(ts.addr, testNames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := player.do("new nothing", "Huh?"); err != nil {
+		t.Error(err)
+	}
+
+	ts.shutdown(t, admin)
+	if _, err := loadPlayerFile("Boss"); err != errNoPlayer {
+		t.Errorf("Boss was created: %v", err)
+	}
+	for name, want := range map[string]bool{"Admin": true, "Chief": true, testNames[0]: false} {
+		pf, err := loadPlayerFile(name)
+		if err != nil {
+			t.Errorf("%s wasn't saved: %v", name, err)
+			continue
+		}
+		if pf.Admin != want {
+			t.Errorf("%s saved with admin %v", name, pf.Admin)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultPrompt string = "Exits: %x"
+	statusPrompt  string = "<%h/%Hhp %m/%Mm %v/%Vmv> Exits: %x"
+	maxPromptLen  int    = 120
+)
+
+// what each %token in a prompt template is replaced with
+var promptTokens = []struct {
+	token string
+	desc  string
+	value func(u *User) string
+}{
+	{"h", "hp", func(u *User) string { return fmt.Sprint(u.char.hp) }},
+	{"H", "max hp", func(u *User) string { return fmt.Sprint(u.char.stats().hp) }},
+	{"m", "mana", func(u *User) string { return fmt.Sprint(u.char.mana) }},
+	{"M", "max mana", func(u *User) string { return fmt.Sprint(u.char.stats().mana) }},
+	{"v", "moves", func(u *User) string { return fmt.Sprint(u.char.moves) }},
+	{"V", "max moves", func(u *User) string { return fmt.Sprint(u.char.stats().moves) }},
+	{"x", "exits", func(u *User) string { return u.room.exitLetters(u) }},
+	{"r", "room name", func(u *User) string { return u.room.name }},
+	{"g", "gold", func(u *User) string { return fmt.Sprint(u.char.gold) }},
+	{"%", "a % sign", func(u *User) string { return "%" }},
+}
+
+// fills in a prompt template for u, unknown tokens are left as they are
+func (u *User) renderPrompt(tmpl string) string {
+	b := strings.Builder{}
+	for n := 0; n < len(tmpl); n++ {
+		if tmpl[n] != '%' || n == len(tmpl)-1 {
+			b.WriteByte(tmpl[n])
+			continue
+		}
+		found := false
+		for _, t := range promptTokens {
+			if tmpl[n+1] == t.token[0] {
+				b.WriteString(t.value(u))
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.WriteByte('%')
+			continue
+		}
+		n++
+	}
+	return b.String()
+}
+
+// queues msg for the user, their prompt follows once everything going out right now has been sent
+func (u *User) send(msg string) {
+	// output that turns up while a compact prompt is waiting for input starts on a line of its own
+	if u.promptOpen {
+		u.session.WriteLine("")
+		u.promptOpen = false
+	}
+	u.session.WriteLine(msg)
+	u.promptDue = true
+}
+
+// writes the prompt of everyone who has had output since their last one. called by the game
+// loop once it's done with an event or pulse, so a burst of messages gets a single prompt
+func (w *World) flushPrompts() {
+	for _, u := range w.users {
+		if !u.promptDue {
+			continue
+		}
+		u.promptDue = false
+		// whatever is waiting on the user's input asks its own questions
+		if u.topHandler() != nil {
+			continue
+		}
+		if u.char.blankLine {
+			u.session.WriteLine("")
+		}
+		if u.char.compact {
+			u.session.Write(u.getPrompt(u.room) + " ")
+			u.promptOpen = true
+		} else {
+			u.session.WriteLine(u.getPrompt(u.room))
+		}
+	}
+}
+
+// prompt [on|off|default|compact|blank|<template>]
+func promptCmd(usr *User, args []string, w *World) {
+	c := usr.char
+	arg := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch arg {
+	case "":
+		tmpl := c.prompt
+		if tmpl == "" {
+			tmpl = defaultPrompt
+		}
+		usr.session.WriteLine("Your prompt is: " + color("cyan", tmpl))
+		usr.session.WriteLine(fmt.Sprintf("Compact is %s, blank lines are %s.", onOff(c.compact), onOff(c.blankLine)))
+		usr.session.WriteLine("Tokens you can use:")
+		for _, t := range promptTokens {
+			usr.session.WriteLine(fmt.Sprintf("    %%%s  %s", t.token, t.desc))
+		}
+		usr.session.WriteLine("'prompt on' shows hp, mana and moves, 'prompt default' goes back to just exits.")
+		return
+	case "on":
+		c.prompt = statusPrompt
+	case "off", "default":
+		c.prompt = ""
+	case "compact":
+		c.compact = !c.compact
+		usr.session.WriteLine(fmt.Sprintf("Compact prompt %s, you'll type on the same line as it.", onOff(c.compact)))
+		return
+	case "blank":
+		c.blankLine = !c.blankLine
+		usr.session.WriteLine(fmt.Sprintf("Blank line before the prompt %s.", onOff(c.blankLine)))
+		return
+	default:
+		if len(arg) > maxPromptLen {
+			usr.session.WriteLine(color("magenta", fmt.Sprintf("Prompts can be at most %d characters.", maxPromptLen)))
+			return
+		}
+		c.prompt = arg
+	}
+	usr.session.WriteLine("Prompt set.")
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -1,5 +1,5 @@
 module github.com/MadArkael/MudServer
 
-go 1.19
+go 1.24
 
 require go4.org v0.0.0-20201209231011-d4a079459e60
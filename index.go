@@ -0,0 +1,82 @@
+package main
+
+import "strings"
+
+// the world keeps rooms by id and players by name next to its lists, and each room keeps where each of
+// its players sits in its list, so nothing has to walk every room or player to find or remove one.
+// everything goes through these so the lists and indexes never disagree
+
+func (w *World) addRoom(r *Room) {
+	w.rooms = append(w.rooms, r)
+	w.roomIndex[r.id] = r
+}
+
+func getRoomByID(id int, w *World) *Room {
+	return w.roomIndex[id]
+}
+
+// adds u to the players online, doing nothing if they already are
+func (w *World) addUser(u *User) {
+	if w.hasUser(u) {
+		return
+	}
+	w.userAt[u] = len(w.users)
+	w.users = append(w.users, u)
+	w.userIndex[strings.ToLower(u.name)] = u
+}
+
+// takes u out of the players online. the last player takes their place in the list
+func (w *World) removeUser(u *User) bool {
+	n, ok := w.userAt[u]
+	if !ok {
+		return false
+	}
+	last := w.users[len(w.users)-1]
+	w.users[n] = last
+	w.userAt[last] = n
+	w.users = w.users[:len(w.users)-1]
+	delete(w.userAt, u)
+	delete(w.userIndex, strings.ToLower(u.name))
+	return true
+}
+
+func (w *World) hasUser(u *User) bool {
+	_, ok := w.userAt[u]
+	return ok
+}
+
+// the player online called name, any case
+func (w *World) findUser(name string) *User {
+	return w.userIndex[strings.ToLower(name)]
+}
+
+// adds u to the players in r, doing nothing if they already are
+func (r *Room) addUser(u *User) {
+	if r.userAt == nil {
+		r.userAt = map[*User]int{}
+	}
+	if r.hasUser(u) {
+		return
+	}
+	r.userAt[u] = len(r.users)
+	r.users = append(r.users, u)
+}
+
+// takes u out of the players in r. the last player takes their place in the list
+func (r *Room) removeUser(u *User) bool {
+	n, ok := r.userAt[u]
+	if !ok {
+		return false
+	}
+	last := r.users[len(r.users)-1]
+	r.users[n] = last
+	r.userAt[last] = n
+	r.users = r.users[:len(r.users)-1]
+	delete(r.userAt, u)
+	return true
+}
+
+func (r *Room) hasUser(u *User) bool {
+	_, ok := r.userAt[u]
+	return ok
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// a side by side grid of rooms for the benchmarks, each joined to its neighbours north, south, east and west
+const benchGridSide = 100
+
+func emptyWorld() *World {
+	world := &World{stopped: make(chan struct{}), roomIndex: map[int]*Room{}, userIndex: map[string]*User{}, userAt: map[*User]int{}}
+	world.items = make(map[string]map[int]*Item)
+	return world
+}
+
+// a player whose output goes nowhere, so what's measured is the game's work and not a socket's
+func quietUser(name string, r *Room, w *World) *User {
+	u := &User{name: name, session: &Session{closed: true}, room: r, found: map[*Exit]bool{}}
+	u.char = &Character{name: name, user: u, room: r, str: 15, dex: 10, con: 10, intl: 10, wis: 10, cha: 10,
+		eq: map[string]*Item{}, moves: 1 << 30, maxMoves: 1 << 30}
+	r.addUser(u)
+	w.addUser(u)
+	return u
+}
+
+// builds a side by side square world through addRoom, numbering rooms from 1 a row at a time
+func gridWorld(tb testing.TB, side int) *World {
+	tb.Helper()
+	cfg = defaultConfig()
+	world := emptyWorld()
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			id := y*side + x + 1
+			r := &Room{id: id, name: fmt.Sprintf("Room %d", id), desc: "A room much like the others."}
+			if y > 0 {
+				r.exits = append(r.exits, &Exit{keyword: "north", linkedID: id - side})
+			}
+			if y < side-1 {
+				r.exits = append(r.exits, &Exit{keyword: "south", linkedID: id + side})
+			}
+			if x < side-1 {
+				r.exits = append(r.exits, &Exit{keyword: "east", linkedID: id + 1})
+			}
+			if x > 0 {
+				r.exits = append(r.exits, &Exit{keyword: "west", linkedID: id - 1})
+			}
+			world.addRoom(r)
+		}
+	}
+	w = world
+	// moving used to log every step to stdout
+	stdout := os.Stdout
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+		tb.Cleanup(func() {
+			os.Stdout = stdout
+			devNull.Close()
+		})
+	}
+	return world
+}
+
+// checks every player in users sits where userAt says they do
+func checkUserAt(t *testing.T, users []*User, userAt map[*User]int) {
+	t.Helper()
+	if len(userAt) != len(users) {
+		t.Errorf("%d players indexed, %d in the list", len(userAt), len(users))
+	}
+	for n, u := range users {
+		if at, ok := userAt[u]; !ok || at != n {
+			t.Errorf("%s is at %d, indexed at %d (%v)", u.name, n, at, ok)
+		}
+	}
+}
+
+func TestWorldUserIndex(t *testing.T) {
+	world := emptyWorld()
+	r := &Room{id: 1, name: "Room 1"}
+	world.addRoom(r)
+	a := quietUser("Alpha", r, world)
+	b := quietUser("Bravo", r, world)
+	c := quietUser("Charlie", r, world)
+	world.addUser(b)
+	if len(world.users) != 3 {
+		t.Fatalf("adding Bravo twice left %d players online", len(world.users))
+	}
+	checkUserAt(t, world.users, world.userAt)
+
+	if !world.removeUser(a) {
+		t.Fatal("Alpha wasn't removed")
+	}
+	// the last player fills the gap
+	if len(world.users) != 2 || world.users[0] != c || world.users[1] != b {
+		t.Fatalf("after removing Alpha: %v", world.users)
+	}
+	checkUserAt(t, world.users, world.userAt)
+	if world.removeUser(a) {
+		t.Error("Alpha was removed twice")
+	}
+	if world.hasUser(a) || world.findUser("alpha") != nil {
+		t.Error("Alpha can still be found")
+	}
+	if !world.hasUser(c) || world.findUser("cHARLIE") != c || world.findUser("bravo") != b {
+		t.Error("players still online can't be found by name")
+	}
+
+	world.removeUser(b)
+	world.removeUser(c)
+	if len(world.users) != 0 || len(world.userAt) != 0 || len(world.userIndex) != 0 {
+		t.Errorf("left over after everyone went: %v %v %v", world.users, world.userAt, world.userIndex)
+	}
+}
+
+func TestRoomUsers(t *testing.T) {
+	world := emptyWorld()
+	r := &Room{id: 1, name: "Room 1"}
+	world.addRoom(r)
+	users := []*User{}
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta"} {
+		users = append(users, quietUser(name, r, world))
+	}
+	r.addUser(users[0])
+	if len(r.users) != 4 {
+		t.Fatalf("adding Alpha twice left %d players in the room", len(r.users))
+	}
+	checkUserAt(t, r.users, r.userAt)
+
+	if !r.removeUser(users[1]) {
+		t.Fatal("Bravo wasn't removed")
+	}
+	if len(r.users) != 3 || r.users[1] != users[3] {
+		t.Fatalf("Delta didn't take Bravo's place: %v", r.users)
+	}
+	checkUserAt(t, r.users, r.userAt)
+	if r.removeUser(users[1]) || r.hasUser(users[1]) {
+		t.Error("Bravo is still in the room")
+	}
+	// the last one just goes
+	if !r.removeUser(users[3]) || len(r.users) != 2 {
+		t.Fatalf("after removing Delta: %v", r.users)
+	}
+	checkUserAt(t, r.users, r.userAt)
+
+	// a room made without an index, as loaded rooms are, starts one on first use
+	other := &Room{id: 2, name: "Room 2"}
+	if other.hasUser(users[0]) || other.removeUser(users[0]) {
+		t.Error("an empty room has Alpha in it")
+	}
+	other.addUser(users[0])
+	if !other.hasUser(users[0]) {
+		t.Error("Alpha wasn't added to an empty room")
+	}
+}
+
+func TestAddRoomIndex(t *testing.T) {
+	world := gridWorld(t, 3)
+	if len(world.rooms) != 9 || len(world.roomIndex) != 9 {
+		t.Fatalf("%d rooms, %d indexed", len(world.rooms), len(world.roomIndex))
+	}
+	for _, r := range world.rooms {
+		if getRoomByID(r.id, world) != r {
+			t.Errorf("room %d isn't found by its id", r.id)
+		}
+	}
+	if getRoomByID(10, world) != nil || getRoomByID(0, world) != nil {
+		t.Error("found a room that was never added")
+	}
+}
+
+func TestMoveUserBetweenRooms(t *testing.T) {
+	world := gridWorld(t, 3)
+	from, to := getRoomByID(5, world), getRoomByID(6, world)
+	stay := quietUser("Bravo", from, world)
+	u := quietUser("Alpha", from, world)
+	there := quietUser("Charlie", to, world)
+
+	isMoveValid(u, "east", world)
+	if u.room != to {
+		t.Fatalf("Alpha is in %s", u.room.name)
+	}
+	if from.hasUser(u) || len(from.users) != 1 || from.users[0] != stay {
+		t.Errorf("Alpha is still in the room they left: %v", from.users)
+	}
+	if !to.hasUser(u) || len(to.users) != 2 || to.users[0] != there {
+		t.Errorf("Alpha isn't in the room they went to: %v", to.users)
+	}
+	checkUserAt(t, from.users, from.userAt)
+	checkUserAt(t, to.users, to.userAt)
+	if world.findUser("alpha") != u || len(world.users) != 3 {
+		t.Error("moving changed who's online")
+	}
+
+	// there's no way east from the last column, so nothing changes
+	isMoveValid(there, "east", world)
+	if there.room != to || !to.hasUser(there) {
+		t.Error("Charlie moved through a wall")
+	}
+}
+
+// the middle of the grid, so lookups aren't helped by it being near the start of the list
+func benchMiddle(w *World) *Room {
+	return getRoomByID(benchGridSide*benchGridSide/2+benchGridSide/2, w)
+}
+
+// the benchmark grid, with a player in every tenth room
+func benchWorld(b *testing.B) *World {
+	b.Helper()
+	world := gridWorld(b, benchGridSide)
+	for n, r := range world.rooms {
+		if n%10 == 0 {
+			quietUser(fmt.Sprintf("Player%d", n), r, world)
+		}
+	}
+	return world
+}
+
+func BenchmarkMoveUser(b *testing.B) {
+	w := benchWorld(b)
+	u := quietUser("Walker", benchMiddle(w), w)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		isMoveValid(u, "east", w)
+		isMoveValid(u, "west", w)
+	}
+}
+
+func BenchmarkYell(b *testing.B) {
+	w := benchWorld(b)
+	u := quietUser("Yeller", benchMiddle(w), w)
+	args := []string{"yell", "hello"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		yellCmd(u, args, w)
+	}
+}
+
+func BenchmarkRoomsWithin(b *testing.B) {
+	w := benchWorld(b)
+	r := benchMiddle(w)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.roomsWithin(r, cfg.YellDistance)
+	}
+}
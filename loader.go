@@ -0,0 +1,580 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// on-disk shape of a world file. any file may carry any mix of rooms, items, mobiles, emotes and classes
+type roomData struct {
+	ID     int         `json:"id"`
+	Name   string      `json:"name"`
+	Desc   string      `json:"desc"`
+	Exits  []exitData  `json:"exits"`
+	Resets []resetData `json:"resets"`
+}
+
+type resetData struct {
+	Mobile int `json:"mobile"`
+	Max    int `json:"max"`
+}
+
+type mobileData struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Short  string   `json:"short"`
+	Desc   string   `json:"desc"`
+	Lines  []string `json:"lines"`
+	Wander bool     `json:"wander"`
+	Aggro  int      `json:"aggro,omitempty"`
+	Hp     int      `json:"hp"`
+	Str    int      `json:"str"`
+	Dex    int      `json:"dex"`
+	Con    int      `json:"con"`
+	Att    int      `json:"att"`
+	Dam    int      `json:"dam"`
+}
+
+type exitData struct {
+	Keyword  string    `json:"keyword"`
+	LookMsg  string    `json:"lookMsg"`
+	LinkedID int       `json:"linkedID"`
+	Door     *doorData `json:"door,omitempty"`
+	Hidden   bool      `json:"hidden,omitempty"`
+	OneWay   bool      `json:"oneWay,omitempty"`
+	MinLevel int       `json:"minLevel,omitempty"`
+	Classes  []string  `json:"classes,omitempty"`
+	Needs    int       `json:"needs,omitempty"`
+	Refuse   string    `json:"refuse,omitempty"`
+}
+
+// a door only needs defining on one side of a passage, the exit back shares it
+type doorData struct {
+	Name   string `json:"name,omitempty"`
+	Closed bool   `json:"closed,omitempty"`
+	Locked bool   `json:"locked,omitempty"`
+	Key    int    `json:"key,omitempty"`
+	NoPick bool   `json:"noPick,omitempty"`
+}
+
+type itemData struct {
+	ID        int            `json:"id"`
+	Name      string         `json:"name"`
+	Keywords  []string       `json:"keywords,omitempty"`
+	Short     string         `json:"short,omitempty"`
+	Long      string         `json:"long,omitempty"`
+	Desc      string         `json:"desc"`
+	Slot      string         `json:"slot"`
+	AC        int            `json:"ac"`
+	Dmg       string         `json:"dmg"`
+	Dmgi      int            `json:"dmgi"`
+	Eff       *effectsData   `json:"eff"`
+	Weight    int            `json:"weight,omitempty"`
+	Container *containerData `json:"container,omitempty"`
+}
+
+// present on items that can hold other items. key is the id of the item that locks it
+type containerData struct {
+	Capacity  int  `json:"capacity,omitempty"`
+	MaxWeight int  `json:"maxWeight,omitempty"`
+	Closable  bool `json:"closable,omitempty"`
+	Closed    bool `json:"closed,omitempty"`
+	Locked    bool `json:"locked,omitempty"`
+	Key       int  `json:"key,omitempty"`
+	NoPick    bool `json:"noPick,omitempty"`
+}
+
+type effectsData struct {
+	Str   int `json:"str"`
+	Dex   int `json:"dex"`
+	Con   int `json:"con"`
+	Intl  int `json:"intl"`
+	Wis   int `json:"wis"`
+	Cha   int `json:"cha"`
+	Fort  int `json:"fort"`
+	Ref   int `json:"ref"`
+	Wil   int `json:"wil"`
+	Att   int `json:"att"`
+	Dam   int `json:"dam"`
+	Hp    int `json:"hp"`
+	Mana  int `json:"mana"`
+	Moves int `json:"moves"`
+	Exp   int `json:"exp"`
+}
+
+type classData struct {
+	Name      string `json:"name"`
+	Desc      string `json:"desc"`
+	Hp        int    `json:"hp"`
+	Mana      int    `json:"mana"`
+	Moves     int    `json:"moves"`
+	Fort      int    `json:"fort"`
+	Ref       int    `json:"ref"`
+	Wil       int    `json:"wil"`
+	Att       int    `json:"att"`
+	Equipment []int  `json:"equipment"`
+}
+
+type emoteData struct {
+	Name       string `json:"name"`
+	Self       string `json:"self"`
+	SelfTarget string `json:"selfTarget"`
+	Target     string `json:"target"`
+	Room       string `json:"room"`
+	RoomTarget string `json:"roomTarget"`
+}
+
+// where a decoded entry came from, so validation can point builders at it
+type srcPos struct {
+	file string
+	line int
+}
+
+func (p srcPos) String() string {
+	return fmt.Sprintf("%s:%d", p.file, p.line)
+}
+
+// collects every problem found while loading so builders see them all at once
+type loadErrors []string
+
+func (le *loadErrors) add(pos srcPos, format string, a ...interface{}) {
+	*le = append(*le, pos.String()+": "+fmt.Sprintf(format, a...))
+}
+
+func (le loadErrors) err() error {
+	if len(le) == 0 {
+		return nil
+	}
+	return fmt.Errorf("world data has %d error(s):\r\n    %s", len(le), strings.Join(le, "\r\n    "))
+}
+
+// reads every *.json file in dir and populates rooms, item and mobile prototypes, emotes and classes
+func (w *World) loadWorld(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no world files found in %s", dir)
+	}
+	sort.Strings(files)
+
+	var errs loadErrors
+	roomPos := make(map[int]srcPos)
+	exitsPos := make(map[*Exit]srcPos)
+	itemPos := make(map[int]srcPos)
+	emotePos := make(map[string]srcPos)
+	classPos := make(map[*Class]srcPos)
+	resetPos := make(map[*Reset]srcPos)
+	mobPos := make(map[int]srcPos)
+	w.rooms = []*Room{}
+	w.roomIndex = map[int]*Room{}
+	w.mobProtos = make(map[int]*Mobile)
+	w.emotes = []*Emote{}
+	w.classes = []*Class{}
+	w.itemFiles = make(map[int]string)
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		err = decodeWorldFile(path, data, func(key string, pos srcPos, dec *json.Decoder) error {
+			switch key {
+			case "rooms":
+				var rd roomData
+				if err := dec.Decode(&rd); err != nil {
+					return err
+				}
+				if rd.ID <= 0 {
+					errs.add(pos, "room has missing or invalid id %d", rd.ID)
+					return nil
+				}
+				if prev, ok := roomPos[rd.ID]; ok {
+					errs.add(pos, "room id %d already defined at %s", rd.ID, prev)
+					return nil
+				}
+				if rd.Name == "" {
+					errs.add(pos, "room %d has no name", rd.ID)
+				}
+				roomPos[rd.ID] = pos
+				rm := &Room{name: rd.Name, desc: rd.Desc, id: rd.ID, items: []*Item{}, exits: []*Exit{}}
+				for _, ed := range rd.Exits {
+					if ed.Keyword == "" {
+						errs.add(pos, "room %d has an exit with no keyword", rd.ID)
+						continue
+					}
+					// n and the like are spelled out so exits are always found by their full name
+					ed.Keyword = exitWord(ed.Keyword)
+					if rm.getExit(ed.Keyword) != nil {
+						errs.add(pos, "room %d has more than one '%s' exit", rd.ID, ed.Keyword)
+						continue
+					}
+					ex := &Exit{keyword: ed.Keyword, lookMsg: ed.LookMsg, linkedID: ed.LinkedID, hidden: ed.Hidden, oneWay: ed.OneWay,
+						minLevel: ed.MinLevel, classes: ed.Classes, needs: ed.Needs, refuse: ed.Refuse}
+					if dd := ed.Door; dd != nil {
+						if dd.Locked && (!dd.Closed || dd.Key == 0) {
+							errs.add(pos, "room %d '%s' door starts locked but isn't closed or has no key", rd.ID, ed.Keyword)
+						}
+						if dd.Name == "" {
+							dd.Name = "door"
+						}
+						ex.door = &Door{name: strings.ToLower(dd.Name), Closure: Closure{closable: true, closed: dd.Closed, locked: dd.Locked, key: dd.Key, noPick: dd.NoPick}}
+					}
+					rm.exits = append(rm.exits, ex)
+					exitsPos[ex] = pos
+				}
+				for _, rsd := range rd.Resets {
+					if rsd.Max <= 0 {
+						errs.add(pos, "room %d reset for mobile %d needs a max above 0", rd.ID, rsd.Mobile)
+						continue
+					}
+					rs := &Reset{mobID: rsd.Mobile, max: rsd.Max, room: rm}
+					rm.resets = append(rm.resets, rs)
+					resetPos[rs] = pos
+				}
+				w.addRoom(rm)
+			case "items":
+				var id itemData
+				if err := dec.Decode(&id); err != nil {
+					return err
+				}
+				if id.ID <= 0 {
+					errs.add(pos, "item has missing or invalid id %d", id.ID)
+					return nil
+				}
+				if prev, ok := itemPos[id.ID]; ok {
+					errs.add(pos, "item id %d already defined at %s", id.ID, prev)
+					return nil
+				}
+				itemPos[id.ID] = pos
+				itm, msg := id.toItem(w)
+				if msg != "" {
+					errs.add(pos, "item %d %s", id.ID, msg)
+					return nil
+				}
+				if _, ok := w.items[itm.name]; ok {
+					errs.add(pos, "item %d reuses the name '%s'", id.ID, itm.name)
+					return nil
+				}
+				addItem(w.items, itm)
+				w.itemFiles[itm.id] = path
+			case "mobiles":
+				var md mobileData
+				if err := dec.Decode(&md); err != nil {
+					return err
+				}
+				if md.ID <= 0 {
+					errs.add(pos, "mobile has missing or invalid id %d", md.ID)
+					return nil
+				}
+				if prev, ok := mobPos[md.ID]; ok {
+					errs.add(pos, "mobile id %d already defined at %s", md.ID, prev)
+					return nil
+				}
+				mobPos[md.ID] = pos
+				if md.Name == "" || md.Short == "" {
+					errs.add(pos, "mobile %d needs both a name and a short description", md.ID)
+				}
+				if md.Aggro < 0 {
+					errs.add(pos, "mobile %d aggro can't be negative", md.ID)
+				}
+				w.mobProtos[md.ID] = &Mobile{
+					id:     md.ID,
+					name:   md.Name,
+					short:  md.Short,
+					desc:   md.Desc,
+					lines:  md.Lines,
+					wander: md.Wander,
+					aggro:  md.Aggro,
+					char:   &Character{str: md.Str, dex: md.Dex, con: md.Con, att: md.Att, dam: md.Dam, maxHp: md.Hp},
+				}
+			case "emotes":
+				var ed emoteData
+				if err := dec.Decode(&ed); err != nil {
+					return err
+				}
+				if ed.Name == "" {
+					errs.add(pos, "emote has no name")
+					return nil
+				}
+				if prev, ok := emotePos[ed.Name]; ok {
+					errs.add(pos, "emote '%s' already defined at %s", ed.Name, prev)
+					return nil
+				}
+				if ed.Self == "" || ed.SelfTarget == "" || ed.Target == "" || ed.Room == "" || ed.RoomTarget == "" {
+					errs.add(pos, "emote '%s' is missing one of self, selfTarget, target, room or roomTarget", ed.Name)
+				}
+				emotePos[ed.Name] = pos
+				w.emotes = append(w.emotes, &Emote{name: ed.Name, fP: ed.Self, fPt: ed.SelfTarget, tar: ed.Target, tP: ed.Room, tPt: ed.RoomTarget})
+			case "classes":
+				var cd classData
+				if err := dec.Decode(&cd); err != nil {
+					return err
+				}
+				if cd.Name == "" {
+					errs.add(pos, "class has no name")
+					return nil
+				}
+				if c := w.findClass(cd.Name); c != nil && strings.EqualFold(c.name, cd.Name) {
+					errs.add(pos, "class '%s' already defined at %s", cd.Name, classPos[c])
+					return nil
+				}
+				if cd.Hp <= 0 || cd.Moves <= 0 || cd.Mana < 0 {
+					errs.add(pos, "class '%s' needs hp and moves above 0 and mana of 0 or more", cd.Name)
+				}
+				c := &Class{name: cd.Name, desc: cd.Desc, hp: cd.Hp, mana: cd.Mana, moves: cd.Moves,
+					fort: cd.Fort, ref: cd.Ref, wil: cd.Wil, att: cd.Att, equip: cd.Equipment}
+				classPos[c] = pos
+				w.classes = append(w.classes, c)
+			default:
+				errs.add(pos, "unknown section '%s', expected rooms, items, mobiles, emotes or classes", key)
+				var skip json.RawMessage
+				return dec.Decode(&skip)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// exits can point at rooms from any file, so links are checked once everything is read
+	for _, rm := range w.rooms {
+		for _, ex := range rm.exits {
+			if _, ok := roomPos[ex.linkedID]; !ok {
+				errs.add(exitsPos[ex], "room %d exit '%s' links to unknown room %d", rm.id, ex.keyword, ex.linkedID)
+				continue
+			}
+			// exits are one way unless the room they lead to has one back, saying so catches typos
+			switch back := getRoomByID(ex.linkedID, w).leadsTo(rm); {
+			case ex.oneWay && back:
+				errs.add(exitsPos[ex], "room %d exit '%s' is one way but room %d leads back", rm.id, ex.keyword, ex.linkedID)
+			case !ex.oneWay && !back:
+				errs.add(exitsPos[ex], "room %d exit '%s' has no way back from room %d, mark it oneWay if that's meant", rm.id, ex.keyword, ex.linkedID)
+			}
+			if _, ok := itemPos[ex.needs]; ex.needs != 0 && !ok {
+				errs.add(exitsPos[ex], "room %d exit '%s' needs unknown item %d", rm.id, ex.keyword, ex.needs)
+			}
+			for n, name := range ex.classes {
+				if c := w.findClass(name); c != nil && strings.EqualFold(c.name, name) {
+					ex.classes[n] = c.name
+				} else {
+					errs.add(exitsPos[ex], "room %d exit '%s' allows unknown class '%s'", rm.id, ex.keyword, name)
+				}
+			}
+			if ex.door == nil {
+				continue
+			}
+			if _, ok := itemPos[ex.door.key]; ex.door.key != 0 && !ok {
+				errs.add(exitsPos[ex], "room %d '%s' door is locked by unknown item %d", rm.id, ex.keyword, ex.door.key)
+			}
+			// both sides of a door share it so opening one opens the other
+			if _, back := w.reverseExit(rm, ex); back != nil {
+				if back.door != nil && back.door != ex.door {
+					errs.add(exitsPos[ex], "room %d '%s' door is also defined on the way back, at %s", rm.id, ex.keyword, exitsPos[back])
+					continue
+				}
+				back.door = ex.door
+			}
+		}
+		for _, rs := range rm.resets {
+			if _, ok := w.mobProtos[rs.mobID]; !ok {
+				errs.add(resetPos[rs], "room %d resets unknown mobile %d", rm.id, rs.mobID)
+			}
+		}
+	}
+	for _, c := range w.classes {
+		for _, id := range c.equip {
+			if _, ok := itemPos[id]; !ok {
+				errs.add(classPos[c], "class '%s' starts with unknown item %d", c.name, id)
+			}
+		}
+	}
+	// keys may be defined after the containers they open
+	for _, m := range w.items {
+		if p := m[0]; p != nil && p.store != nil && p.store.key != 0 {
+			if _, ok := itemPos[p.store.key]; !ok {
+				errs.add(itemPos[p.id], "item %d is locked by unknown item %d", p.id, p.store.key)
+			}
+		}
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+	fmt.Printf("Loaded %d rooms, %d items, %d mobiles, %d emotes and %d classes from %s\r\n", len(w.rooms), len(w.items), len(w.mobProtos), len(w.emotes), len(w.classes), dir)
+	return nil
+}
+
+// walks the top level object of a world file, calling entry for each element of each section array
+func decodeWorldFile(path string, data []byte, entry func(key string, pos srcPos, dec *json.Decoder) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	// InputOffset sits just past the previous token, skip to where the next one starts
+	start := func(off int64) int64 {
+		for off < int64(len(data)) && strings.ContainsRune(" \t\r\n,:", rune(data[off])) {
+			off++
+		}
+		return off
+	}
+	lineAt := func(off int64) srcPos {
+		if off > int64(len(data)) {
+			off = int64(len(data))
+		}
+		return srcPos{path, 1 + bytes.Count(data[:off], []byte("\n"))}
+	}
+	at := func(off int64) srcPos {
+		return lineAt(start(off))
+	}
+	wrap := func(off int64, err error) error {
+		pos := at(off)
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			// syntax errors point just past the offending byte
+			pos = lineAt(e.Offset - 1)
+		case *json.UnmarshalTypeError:
+			// type errors are reported relative to the value being decoded
+			pos = lineAt(start(off) + e.Offset)
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("%s: %v", pos, err)
+	}
+	expect := func(want json.Delim) error {
+		off := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return wrap(off, err)
+		}
+		if d, ok := tok.(json.Delim); !ok || d != want {
+			return fmt.Errorf("%s: expected '%s', found %v", at(off), want, tok)
+		}
+		return nil
+	}
+
+	if err := expect('{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		off := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return wrap(off, err)
+		}
+		key := tok.(string)
+		if err := expect('['); err != nil {
+			return err
+		}
+		for dec.More() {
+			off := dec.InputOffset()
+			if err := entry(key, at(off), dec); err != nil {
+				return wrap(off, err)
+			}
+		}
+		if err := expect(']'); err != nil {
+			return err
+		}
+	}
+	return expect('}')
+}
+
+// builds an item prototype, returns a message describing why the data is invalid if it is
+func (id *itemData) toItem(w *World) (*Item, string) {
+	if id.Name == "" {
+		return nil, "has no name"
+	}
+	slot := ""
+	for _, s := range w.eqList {
+		if strings.EqualFold(s, id.Slot) {
+			slot = s
+		}
+	}
+	if slot == "" {
+		return nil, fmt.Sprintf("has unknown slot '%s'", id.Slot)
+	}
+	if id.Dmg != "" && !isValidDice(id.Dmg) {
+		return nil, fmt.Sprintf("has damage '%s', expected the form 2d4", id.Dmg)
+	}
+	if id.Weight < 0 {
+		return nil, "has a negative weight"
+	}
+	if c := id.Container; c != nil {
+		if c.Capacity < 0 || c.MaxWeight < 0 {
+			return nil, "has a negative container limit"
+		}
+		if c.Closed && !c.Closable {
+			return nil, "starts closed but isn't closable"
+		}
+		if c.Locked && (!c.Closed || c.Key == 0) {
+			return nil, "starts locked but isn't closed or has no key"
+		}
+	}
+	itm := &Item{
+		id:     id.ID,
+		name:   id.Name,
+		short:  id.Short,
+		long:   id.Long,
+		desc:   id.Desc,
+		slot:   slot,
+		uID:    fmt.Sprint(id.ID) + "|" + time.Now().Format(time.RFC3339),
+		ac:     id.AC,
+		dmg:    id.Dmg,
+		dmgi:   id.Dmgi,
+		weight: id.Weight,
+	}
+	if c := id.Container; c != nil {
+		itm.store = &Storage{capacity: c.Capacity, maxWeight: c.MaxWeight,
+			Closure: Closure{closable: c.Closable, closed: c.Closed, locked: c.Locked, key: c.Key, noPick: c.NoPick}}
+	}
+	for _, k := range id.Keywords {
+		itm.keywords = append(itm.keywords, strings.ToLower(k))
+	}
+	itm.fillDescs()
+	if e := id.Eff; e != nil {
+		itm.eff = &Effects{
+			str: e.Str, dex: e.Dex, con: e.Con, intl: e.Intl, wis: e.Wis, cha: e.Cha,
+			fort: e.Fort, ref: e.Ref, wil: e.Wil, att: e.Att, dam: e.Dam,
+			hp: e.Hp, mana: e.Mana, moves: e.Moves, exp: e.Exp,
+		}
+	}
+	return itm, ""
+}
+
+// the reverse of toItem, for writing prototypes back out
+func (i *Item) toData() itemData {
+	id := itemData{ID: i.id, Name: i.name, Keywords: i.keywords, Short: i.short, Long: i.long, Desc: i.desc, Slot: i.slot, AC: i.ac, Dmg: i.dmg, Dmgi: i.dmgi, Weight: i.weight}
+	if s := i.store; s != nil {
+		id.Container = &containerData{Capacity: s.capacity, MaxWeight: s.maxWeight, Closable: s.closable, Closed: s.closed, Locked: s.locked, Key: s.key, NoPick: s.noPick}
+	}
+	if e := i.eff; e != nil {
+		id.Eff = &effectsData{
+			Str: e.str, Dex: e.dex, Con: e.con, Intl: e.intl, Wis: e.wis, Cha: e.cha,
+			Fort: e.fort, Ref: e.ref, Wil: e.wil, Att: e.att, Dam: e.dam,
+			Hp: e.hp, Mana: e.mana, Moves: e.moves, Exp: e.exp,
+		}
+	}
+	return id
+}
+
+// true for dice strings like 2d4
+func isValidDice(dice string) bool {
+	both := strings.Split(dice, "d")
+	if len(both) != 2 {
+		return false
+	}
+	qty, err := strconv.Atoi(both[0])
+	if err != nil || qty < 1 {
+		return false
+	}
+	sides, err := strconv.Atoi(both[1])
+	return err == nil && sides > 0
+}
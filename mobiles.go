@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	mobileTickSeconds  int = 10
+	mobileLineChance   int = 15
+	mobileWanderChance int = 20
+	roomResetMinutes   int = 3
+)
+
+// prototypes live in World.mobProtos, spawned copies in World.mobiles and their room
+type Mobile struct {
+	id     int
+	name   string
+	short  string
+	desc   string
+	lines  []string
+	wander bool
+	// how many rooms away it notices players and comes for them, 0 if it leaves them be
+	aggro int
+	reset *Reset
+	char  *Character
+}
+
+// keeps up to max copies of a mobile prototype alive, spawning them in room
+type Reset struct {
+	mobID int
+	max   int
+	room  *Room
+}
+
+func (r *Room) addMobile(m *Mobile) {
+	r.mobs = append(r.mobs, m)
+	m.char.room = r
+}
+
+func (r *Room) removeMobile(m *Mobile) {
+	for n, mob := range r.mobs {
+		if mob == m {
+			r.mobs = append(r.mobs[:n], r.mobs[n+1:]...)
+			return
+		}
+	}
+}
+
+// creates a live copy of proto in r
+func (w *World) spawnMobile(proto *Mobile, r *Room, rs *Reset) *Mobile {
+	m := &Mobile{
+		id:     proto.id,
+		name:   proto.name,
+		short:  proto.short,
+		desc:   proto.desc,
+		lines:  proto.lines,
+		wander: proto.wander,
+		aggro:  proto.aggro,
+		reset:  rs,
+	}
+	pc := proto.char
+	m.char = &Character{
+		name:   proto.name,
+		mob:    m,
+		desc:   proto.desc,
+		str:    pc.str,
+		dex:    pc.dex,
+		con:    pc.con,
+		att:    pc.att,
+		dam:    pc.dam,
+		maxHp:  pc.maxHp,
+		hp:     pc.maxHp,
+		eq:     map[string]*Item{},
+		inv:    []*Item{},
+		status: pc.status,
+	}
+	m.char.fillDefaults()
+	r.addMobile(m)
+	w.mobiles = append(w.mobiles, m)
+	return m
+}
+
+// takes a dead or purged mobile out of the world
+func (w *World) removeMobile(m *Mobile) {
+	if r := m.char.room; r != nil {
+		r.removeMobile(m)
+	}
+	for n, mob := range w.mobiles {
+		if mob == m {
+			w.mobiles = append(w.mobiles[:n], w.mobiles[n+1:]...)
+			return
+		}
+	}
+}
+
+// tops every reset back up to its max, wherever its mobiles have wandered off to
+func (w *World) resetRooms() {
+	alive := make(map[*Reset]int)
+	for _, m := range w.mobiles {
+		if m.reset != nil {
+			alive[m.reset]++
+		}
+	}
+	for _, r := range w.rooms {
+		for _, rs := range r.resets {
+			proto := w.mobProtos[rs.mobID]
+			for i := alive[rs]; i < rs.max; i++ {
+				m := w.spawnMobile(proto, r, rs)
+				r.sendAll(color("yellow", fmt.Sprintf("%s appears.", capFirst(m.name))))
+			}
+		}
+	}
+}
+
+// ambient lines and wandering for every mobile not busy fighting
+func mobileTick(w *World) {
+	mobs := make([]*Mobile, len(w.mobiles))
+	copy(mobs, w.mobiles)
+	for _, m := range mobs {
+		if m.char.fighting != nil {
+			continue
+		}
+		r := m.char.room
+		if len(m.lines) > 0 && rand.Intn(100) < mobileLineChance {
+			r.sendAll(color("yellow", m.lines[rand.Intn(len(m.lines))]))
+		}
+		if m.aggro > 0 && m.hunt(w) {
+			continue
+		}
+		if m.wander && len(r.exits) > 0 && rand.Intn(100) < mobileWanderChance {
+			m.move(r.exits[rand.Intn(len(r.exits))], w)
+		}
+	}
+}
+
+// takes m through ex, if it's a plain open path. mobiles don't use the others
+func (m *Mobile) move(ex *Exit, w *World) bool {
+	if !ex.isOpen() || ex.restricted() || ex.oneWay {
+		return false
+	}
+	r, to := m.char.room, getRoomByID(ex.linkedID, w)
+	r.removeMobile(m)
+	r.sendAll(color("green", fmt.Sprintf("%s leaves %s.", capFirst(m.name), heading(ex.keyword))))
+	to.sendAll(color("green", strings.TrimSpace(fmt.Sprintf("%s arrives %s", capFirst(m.name), arrivalFrom(ex.keyword)))+"."))
+	to.addMobile(m)
+	return true
+}
+
+// an aggressive mobile attacks a player in its room, or heads for the nearest one it can sense.
+// players still answering a prompt, like making their character, are left alone
+func (m *Mobile) hunt(w *World) bool {
+	for _, near := range w.roomsWithin(m.char.room, m.aggro) {
+		prey := []*User{}
+		for _, u := range near.room.users {
+			if u.topHandler() == nil {
+				prey = append(prey, u)
+			}
+		}
+		if len(prey) == 0 {
+			continue
+		}
+		if near.dist > 0 {
+			return m.move(near.via, w)
+		}
+		t := prey[rand.Intn(len(prey))].char
+		m.char.fighting = t
+		if t.fighting == nil {
+			t.fighting = m.char
+		}
+		t.send(color("red", fmt.Sprintf("%s attacks you!", capFirst(m.name))))
+		near.room.sendAll(fmt.Sprintf("%s attacks %s!", color("cyan", capFirst(m.name)), color("cyan", t.name)), t)
+		return true
+	}
+	return false
+}
+
+// examiner looks over a mobile
+func exaMobile(examiner *User, m *Mobile) {
+	examiner.session.WriteLine("You look at " + color("cyan", m.name) + ".")
+	examiner.session.WriteLine("    " + m.desc)
+	examiner.session.WriteLine("    " + m.char.condition())
+	for _, nt := range examiner.room.users {
+		if nt != examiner {
+			nt.send(color("cyan", examiner.name) + " looks " + m.name + " over.")
+		}
+	}
+}
+
+// rough description of how hurt a character is
+func (c *Character) condition() string {
+	pct := 100
+	if max := c.stats().hp; max > 0 {
+		pct = c.hp * 100 / max
+	}
+	name := capFirst(c.name)
+	switch {
+	case pct >= 100:
+		return name + " is in excellent condition."
+	case pct >= 75:
+		return name + " has a few scratches."
+	case pct >= 50:
+		return name + " has some nasty wounds."
+	case pct >= 25:
+		return name + " is bleeding freely."
+	default:
+		return name + " is on death's door."
+	}
+}
+
+// mobile names usually start with an article, this makes them start a sentence
+func capFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
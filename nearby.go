@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// how many rooms away scan sees, and what it calls each distance
+const scanDistance int = 2
+
+var scanRange = []string{"here", "close by", "a little way off"}
+
+// a room roomsWithin found: how many exits away it is, the exit out of the room the search started in
+// that leads toward it, and the exit in it leading back the way the search came, nil for a one way passage
+type nearRoom struct {
+	room *Room
+	dist int
+	via  *Exit
+	back *Exit
+}
+
+// every room within distance exits of r, nearest first, starting with r itself at distance 0.
+// closed doors and hidden passages stop the search, so they stop sound and sight too
+func (w *World) roomsWithin(r *Room, distance int) []nearRoom {
+	found := []nearRoom{{room: r}}
+	seen := map[*Room]bool{r: true}
+	for n := 0; n < len(found); n++ {
+		near := found[n]
+		if near.dist >= distance {
+			break
+		}
+		for _, ex := range near.room.exits {
+			if ex.hidden || !ex.isOpen() {
+				continue
+			}
+			to := getRoomByID(ex.linkedID, w)
+			if to == nil || seen[to] {
+				continue
+			}
+			seen[to] = true
+			via := near.via
+			if via == nil {
+				via = ex
+			}
+			var back *Exit
+			for _, b := range to.exits {
+				if b.linkedID == near.room.id {
+					back = b
+					break
+				}
+			}
+			found = append(found, nearRoom{to, near.dist + 1, via, back})
+		}
+	}
+	return found
+}
+
+// where something from the room the search started in seems to come from, to whoever's in near.room
+func (near nearRoom) from() string {
+	if near.back == nil {
+		return "from somewhere nearby"
+	}
+	return soundFrom(near.back.keyword)
+}
+
+// lets everyone within distance of r, but not in it, hear something. msg has a %s for where it came
+// from, like 'from the east'
+func (w *World) soundAround(r *Room, distance int, msg string) {
+	for _, near := range w.roomsWithin(r, distance)[1:] {
+		near.room.sendAll(fmt.Sprintf(msg, near.from()))
+	}
+}
+
+// scan, who's about in the rooms nearby
+func scanCmd(usr *User, args []string, w *World) {
+	usr.session.WriteLine("You scan your surroundings.")
+	usr.room.sendAll(fmt.Sprintf("%s scans their surroundings.", color("cyan", usr.name)), usr.char)
+	seen := false
+	for _, near := range w.roomsWithin(usr.room, scanDistance)[1:] {
+		names := []string{}
+		for _, u := range near.room.users {
+			names = append(names, u.name)
+		}
+		for _, m := range near.room.mobs {
+			names = append(names, m.name)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		seen = true
+		usr.session.WriteLine(fmt.Sprintf("%s, %s: %s", capFirst(exitPlace(near.via.keyword)), scanRange[near.dist], color("cyan", strings.Join(names, ", "))))
+	}
+	if !seen {
+		usr.session.WriteLine("You don't see anyone nearby.")
+	}
+}
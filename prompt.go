@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+)
+
+// anything that wants a user's next lines instead of the command parser. handlers stack,
+// the newest one gets input and the one beneath it picks back up when it finishes
+type InputHandler interface {
+	// (re)shows whatever the handler is waiting on
+	ask(u *User)
+	// takes one line, returns true once the handler is finished
+	handle(u *User, line string) bool
+}
+
+func (u *User) pushHandler(h InputHandler) {
+	u.handlers = append(u.handlers, h)
+	h.ask(u)
+}
+
+// takes h off the stack, if it was on top the handler now on top is asked again
+func (u *User) removeHandler(h InputHandler) {
+	for n, hh := range u.handlers {
+		if hh == h {
+			wasTop := n == len(u.handlers)-1
+			u.handlers = append(u.handlers[:n], u.handlers[n+1:]...)
+			if top := u.topHandler(); wasTop && top != nil {
+				top.ask(u)
+			}
+			return
+		}
+	}
+}
+
+func (u *User) topHandler() InputHandler {
+	if len(u.handlers) == 0 {
+		return nil
+	}
+	return u.handlers[len(u.handlers)-1]
+}
+
+// hands line to the top handler, returns false if there wasn't one
+func (u *User) handleLine(line string) bool {
+	h := u.topHandler()
+	if h == nil {
+		return false
+	}
+	// a finished handler may have pushed a follow up, so remove it by identity rather than popping
+	if h.handle(u, line) {
+		u.removeHandler(h)
+	}
+	return true
+}
+
+// one step of a Prompt. check can rewrite the answer (say, to a canonical slot name) or
+// return a reason it was refused, in which case the question is asked again
+type Question struct {
+	text  string
+	check func(u *User, answer string) (string, string)
+}
+
+// asks its questions in order, then hands every answer to done. 'abort' cancels at any point
+type Prompt struct {
+	name      string
+	questions []Question
+	answers   []string
+	done      func(u *User, answers []string)
+	aborted   func(u *User)
+}
+
+func (p *Prompt) ask(u *User) {
+	if len(p.answers) == 0 {
+		u.session.WriteLine(color("magenta", p.name+". Type 'abort' at any time to cancel."))
+	}
+	u.session.WriteLine(p.questions[len(p.answers)].text)
+}
+
+func (p *Prompt) handle(u *User, line string) bool {
+	if strings.EqualFold(line, "abort") {
+		u.session.WriteLine(color("magenta", p.name+" aborted."))
+		if p.aborted != nil {
+			p.aborted(u)
+		}
+		return true
+	}
+	q := p.questions[len(p.answers)]
+	if q.check != nil {
+		answer, why := q.check(u, line)
+		if why != "" {
+			u.session.WriteLine(color("magenta", why))
+			u.session.WriteLine(q.text)
+			return false
+		}
+		line = answer
+	}
+	p.answers = append(p.answers, line)
+	if len(p.answers) == len(p.questions) {
+		p.done(u, p.answers)
+		return true
+	}
+	u.session.WriteLine(p.questions[len(p.answers)].text)
+	return false
+}
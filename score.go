@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// longest description describe will take
+const maxDescLen int = 400
+
+// experience it takes to go up a level
+const expPerLevel int = 100
+
+func (c *Character) level() int {
+	return 1 + c.exp/expPerLevel
+}
+
+// score/stats, the character sheet. stats changed by gear show what they are without it in brackets
+func scoreCmd(usr *User, args []string, w *World) {
+	c := usr.char
+	b, s := c.baseStats(), c.stats()
+	stat := func(name string, base, now int) string {
+		v := fmt.Sprint(now)
+		if now != base {
+			v += fmt.Sprintf(" (%d)", base)
+		}
+		cell := fmt.Sprintf("%-5s %-9s", name, v)
+		switch {
+		case now > base:
+			return color("green", cell)
+		case now < base:
+			return color("red", cell)
+		}
+		return cell
+	}
+	title := usr.name
+	if c.class != "" {
+		title += " the " + c.class
+	}
+	title += fmt.Sprintf(", level %d", c.level())
+	bar := strings.Repeat("-", 48)
+	rows := [][]string{
+		{stat("Str", b.str, s.str), stat("Fort", b.fort, s.fort), fmt.Sprintf("Hp    %d/%d", c.hp, s.hp)},
+		{stat("Dex", b.dex, s.dex), stat("Ref", b.ref, s.ref), fmt.Sprintf("Mana  %d/%d", c.mana, s.mana)},
+		{stat("Con", b.con, s.con), stat("Wil", b.wil, s.wil), fmt.Sprintf("Moves %d/%d", c.moves, s.moves)},
+		{stat("Int", b.intl, s.intl), stat("Att", b.att, s.att), fmt.Sprintf("Exp   %d", c.exp)},
+		{stat("Wis", b.wis, s.wis), stat("Dam", b.dam, s.dam), fmt.Sprintf("Gold  %d", c.gold)},
+		{stat("Cha", b.cha, s.cha), fmt.Sprintf("%-5s %-9d", "AC", c.armorClass()), fmt.Sprintf("Load  %d/%d", c.carried(), c.carryCapacity())},
+	}
+	usr.session.WriteLine(bar)
+	usr.session.WriteLine(" " + color("cyan", title))
+	usr.session.WriteLine(bar)
+	for _, r := range rows {
+		usr.session.WriteLine(" " + strings.Join(r, "   "))
+	}
+	usr.session.WriteLine(bar)
+	usr.session.WriteLine(" " + c.condition())
+	if c.fighting != nil {
+		usr.session.WriteLine(" You are fighting " + color("cyan", c.fighting.name) + ".")
+	}
+	usr.session.WriteLine(fmt.Sprintf(" You are %s.", c.encumbrance().name))
+}
+
+// describe [text|clear], what others see when they look at you
+func describeCmd(usr *User, args []string, w *World) {
+	c := usr.char
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+	switch {
+	case text == "":
+		if c.desc == "" {
+			usr.session.WriteLine("You haven't described yourself. Type 'describe <text>' to.")
+			return
+		}
+		usr.session.WriteLine("Others see:")
+		usr.session.WriteLine("    " + c.desc)
+	case text == "clear":
+		c.desc = ""
+		usr.session.WriteLine("Description cleared.")
+	case len(text) > maxDescLen:
+		usr.session.WriteLine(color("magenta", fmt.Sprintf("Descriptions can be at most %d characters, that's %d.", maxDescLen, len(text))))
+	default:
+		c.desc = text
+		usr.session.WriteLine("Description set. Others now see:")
+		usr.session.WriteLine("    " + c.desc)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	sessionQueueSize    int = 512
+	sessionWriteTimeout int = 10
+)
+
+var (
+	errSessionClosed   = errors.New("session closed")
+	errSessionDetached = errors.New("session handed off")
+)
+
+// output is queued and written by the session's own goroutine so one stalled client can't hold up anyone else
+type Session struct {
+	conn net.Conn
+	tn   *telnet
+
+	mu     sync.Mutex
+	out    chan []byte
+	closed bool
+	done   chan struct{}
+
+	// held while reading, so detach can wait out a read in progress
+	readMu   sync.Mutex
+	detached bool
+}
+
+func newSession(conn net.Conn) *Session {
+	s := &Session{
+		conn: conn,
+		out:  make(chan []byte, sessionQueueSize),
+		done: make(chan struct{}),
+	}
+	s.tn = newTelnet(conn, s.enqueue)
+	go s.writeLoop()
+	return s
+}
+
+func (s *Session) writeLoop() {
+	failed := false
+	for b := range s.out {
+		if failed {
+			continue
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(time.Duration(sessionWriteTimeout) * time.Second))
+		if _, err := s.conn.Write(b); err != nil {
+			log.Printf("Write to %s failed, dropping connection: %v", s.conn.RemoteAddr(), err)
+			failed = true
+			s.conn.Close()
+		}
+	}
+	s.conn.Close()
+	close(s.done)
+}
+
+// queues b without blocking, a client that lets its queue fill up is disconnected
+func (s *Session) enqueue(b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSessionClosed
+	}
+	select {
+	case s.out <- b:
+		return nil
+	default:
+		log.Printf("Output queue for %s is full, dropping connection", s.conn.RemoteAddr())
+		s.closed = true
+		close(s.out)
+		s.conn.Close()
+		return errSessionClosed
+	}
+}
+
+func (s *Session) WriteLine(str string) error {
+	return s.enqueue([]byte(str + "\r\n"))
+}
+
+// writes str without a line ending, for prompts
+func (s *Session) Write(str string) error {
+	return s.enqueue([]byte(str))
+}
+
+// lets queued output drain, then closes the connection
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.out)
+	}
+}
+
+// waits up to timeout for queued output to be written after Close
+func (s *Session) wait(timeout time.Duration) {
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+	}
+}
+
+// stops any more reads so the connection can be handed to another process
+func (s *Session) detach() {
+	s.mu.Lock()
+	s.detached = true
+	s.conn.SetReadDeadline(time.Now())
+	s.mu.Unlock()
+	s.readMu.Lock()
+	s.readMu.Unlock()
+}
+
+func (s *Session) isDetached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.detached
+}
+
+// a player who sends nothing for cfg.IdleMinutes is told so and disconnected
+func (s *Session) ReadLine() (string, error) {
+	s.readMu.Lock()
+	defer s.readMu.Unlock()
+	// checked and set together so a detach can't slip in and have its deadline pushed back
+	s.mu.Lock()
+	if s.detached {
+		s.mu.Unlock()
+		return "", errSessionDetached
+	}
+	if cfg.IdleMinutes > 0 {
+		s.conn.SetReadDeadline(time.Now().Add(time.Duration(cfg.IdleMinutes) * time.Minute))
+	}
+	s.mu.Unlock()
+	line, err := s.tn.readLine()
+	if s.isDetached() {
+		return "", errSessionDetached
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		s.WriteLine("You have been idle too long, goodbye.")
+		s.Close()
+	}
+	return line, err
+}
+
+// reads a line with the client's local echo turned off
+func (s *Session) ReadPassword() (string, error) {
+	s.tn.setEcho(true)
+	line, err := s.ReadLine()
+	s.tn.setEcho(false)
+	s.WriteLine("")
+	return line, err
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	// the rebooted process finds its handed over sockets through the file named here
+	copyoverEnv         string = "MUD_COPYOVER"
+	sessionDrainSeconds int    = 5
+)
+
+// players are warned when this many seconds are left
+var shutdownWarnings = []int{300, 120, 60, 30, 10, 5}
+
+type shutdownPlan struct {
+	reboot bool
+	at     time.Time
+	by     string
+	warned int
+}
+
+// what the new process needs to pick up each connection where the old one left off.
+// sockets are passed in order after the listener, so the nth user is on fd 4+n
+type copyoverData struct {
+	Users []copyoverUser `json:"users"`
+}
+
+type copyoverUser struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	TType  string `json:"ttype"`
+}
+
+func (p *shutdownPlan) what() string {
+	if p.reboot {
+		return "reboot"
+	}
+	return "shut down"
+}
+
+// shutdown/reboot [seconds|cancel]
+func shutdownCmd(usr *User, args []string, w *World, reboot bool) {
+	if len(args) > 1 && args[1] == "cancel" {
+		if w.shutdown == nil {
+			usr.session.WriteLine(color("magenta", "Nothing is scheduled."))
+			return
+		}
+		w.shutdown = nil
+		for _, u := range w.users {
+			u.send(color("yellow", fmt.Sprintf("%s has called off the %s.", usr.name, args[0])))
+		}
+		return
+	}
+	if reboot && runtime.GOOS == "windows" {
+		usr.session.WriteLine(color("magenta", "Rebooting isn't supported on this platform, use shutdown."))
+		return
+	}
+	secs := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			usr.session.WriteLine(color("magenta", fmt.Sprintf("Usage: %s [seconds|cancel]", args[0])))
+			return
+		}
+		secs = n
+	}
+	w.shutdown = &shutdownPlan{reboot: reboot, at: time.Now().Add(time.Duration(secs) * time.Second), by: usr.name, warned: secs + 1}
+	log.Printf("%s scheduled a %s in %d seconds", usr.name, w.shutdown.what(), secs)
+	w.warnShutdown(secs)
+}
+
+func (w *World) warnShutdown(secs int) {
+	msg := fmt.Sprintf("The server will %s in %d seconds.", w.shutdown.what(), secs)
+	if secs == 0 {
+		msg = fmt.Sprintf("The server will %s now.", w.shutdown.what())
+	}
+	for _, u := range w.users {
+		u.send(color("red", msg))
+	}
+	w.shutdown.warned = secs
+}
+
+// called every pulse, warns players as the time runs down and returns true once the server has stopped
+func (w *World) checkShutdown() bool {
+	p := w.shutdown
+	if p == nil {
+		return false
+	}
+	left := int(time.Until(p.at).Seconds() + 0.5)
+	if left > 0 {
+		for _, s := range shutdownWarnings {
+			if left <= s && p.warned > s {
+				w.warnShutdown(s)
+			}
+		}
+		return false
+	}
+	return w.stop(p.reboot)
+}
+
+// saves everything and closes every connection. a reboot hands the listener and player
+// connections to a fresh copy of the server instead of dropping them. returns false if it couldn't
+func (w *World) stop(reboot bool) bool {
+	var files []*os.File
+	if reboot {
+		var err error
+		if files, err = w.connFiles(); err != nil {
+			log.Println("Reboot failed:", err)
+			for _, u := range w.users {
+				if u.level() >= levelAdmin {
+					u.send(color("magenta", fmt.Sprintf("Reboot failed: %v", err)))
+				}
+			}
+			w.shutdown = nil
+			return false
+		}
+	}
+	log.Println("Stopping server")
+	w.ln.Close()
+	for _, u := range w.users {
+		u.char.stopFighting(w)
+	}
+	saveAllUsers(w)
+	if err := w.saveItems(); err != nil {
+		log.Println("Error saving items:", err)
+	}
+
+	cd := copyoverData{Users: []copyoverUser{}}
+	for _, u := range w.users {
+		if reboot {
+			u.session.detach()
+			u.session.WriteLine(color("red", "Rebooting, hold on..."))
+			cd.Users = append(cd.Users, copyoverUser{u.name, u.session.tn.width, u.session.tn.height, u.session.tn.ttype})
+		} else {
+			u.session.WriteLine(color("red", "The server is shutting down. Your character has been saved, see you soon!"))
+		}
+		u.session.Close()
+	}
+	for _, u := range w.users {
+		u.session.wait(time.Duration(sessionDrainSeconds) * time.Second)
+	}
+	if reboot {
+		if err := startCopyover(cd, files); err != nil {
+			log.Println("Reboot failed, shutting down:", err)
+		}
+	}
+	close(w.stopped)
+	return true
+}
+
+// duplicates the listener and each player's socket so they outlive this process
+func (w *World) connFiles() ([]*os.File, error) {
+	tl, ok := w.ln.(*net.TCPListener)
+	if !ok {
+		return nil, fmt.Errorf("listener can't be handed over")
+	}
+	lf, err := tl.File()
+	if err != nil {
+		return nil, err
+	}
+	files := []*os.File{lf}
+	for _, u := range w.users {
+		tc, ok := u.session.conn.(*net.TCPConn)
+		if !ok {
+			return nil, fmt.Errorf("%s's connection can't be handed over", u.name)
+		}
+		f, err := tc.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func startCopyover(cd copyoverData, files []*os.File) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "mud-copyover-*.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(cd); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), copyoverEnv+"="+f.Name())
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	log.Printf("Handed %d connections to process %d", len(cd.Users), cmd.Process.Pid)
+	return nil
+}
+
+// in a rebooted process, takes over the listener and players the old one handed down
+func resumeCopyover(path string, w *World, inputChannel chan ClientInput) (net.Listener, error) {
+	os.Unsetenv(copyoverEnv)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	cd := copyoverData{}
+	if err := json.Unmarshal(data, &cd); err != nil {
+		return nil, err
+	}
+	lf := os.NewFile(3, "listener")
+	ln, err := net.FileListener(lf)
+	lf.Close()
+	if err != nil {
+		return nil, err
+	}
+	for n, cu := range cd.Users {
+		f := os.NewFile(uintptr(4+n), cu.Name)
+		conn, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			log.Printf("Lost %s's connection in the reboot: %v", cu.Name, err)
+			continue
+		}
+		session := newSession(conn)
+		session.tn.resume(cu)
+		pf, err := loadPlayerFile(cu.Name)
+		if err != nil {
+			log.Printf("Error loading %s after reboot: %v", cu.Name, err)
+			session.WriteLine("Your character couldn't be loaded after the reboot, sorry.")
+			session.Close()
+			continue
+		}
+		user := &User{name: pf.Name, session: session}
+		go serveUser(w, user, &UserJoinedEvent{pf: pf, rebooted: true}, inputChannel)
+	}
+	log.Printf("Reboot picked up %d connections", len(cd.Users))
+	return ln, nil
+}
+
+// the options a rebooted connection already agreed to, so they aren't asked for again
+func (t *telnet) resume(cu copyoverUser) {
+	t.us[tnOptSGA] = tnWILL
+	t.us[tnOptEcho] = tnWONT
+	t.them[tnOptNAWS] = tnDO
+	t.them[tnOptTType] = tnDO
+	t.width, t.height, t.ttype = cu.Width, cu.Height, cu.TType
+}
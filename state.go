@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// prototypes made in game with 'create' are written here, alongside the rest of the world files
+const createdItemsFile string = "created.json"
+
+// items left lying in rooms and how doors were left, so a restart doesn't sweep every floor
+// clean or shut every door
+type worldState struct {
+	Saved time.Time   `json:"saved"`
+	Rooms []roomState `json:"rooms"`
+	Doors []doorState `json:"doors,omitempty"`
+}
+
+type doorState struct {
+	Room  int    `json:"room"`
+	Exit  string `json:"exit"`
+	State string `json:"state"`
+}
+
+type roomState struct {
+	Room  int         `json:"room"`
+	Items []savedItem `json:"items"`
+}
+
+// saves the parts of the item registry that player files don't cover
+func (w *World) saveItems() error {
+	if err := w.saveCreatedItems(); err != nil {
+		return err
+	}
+	ws := worldState{Saved: time.Now(), Rooms: []roomState{}}
+	for _, r := range w.rooms {
+		if items := saveItems(r.items); len(items) > 0 {
+			ws.Rooms = append(ws.Rooms, roomState{r.id, items})
+		}
+		for _, ex := range r.exits {
+			if ex.door != nil {
+				ws.Doors = append(ws.Doors, doorState{r.id, ex.keyword, ex.door.state()})
+			}
+		}
+	}
+	data, err := json.MarshalIndent(ws, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.StateFile), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(cfg.StateFile, data)
+}
+
+func (w *World) saveCreatedItems() error {
+	path := filepath.Join(cfg.WorldDir, createdItemsFile)
+	created := []itemData{}
+	for _, m := range w.items {
+		if p := m[0]; p != nil && (w.itemFiles[p.id] == "" || w.itemFiles[p.id] == path) {
+			created = append(created, p.toData())
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	sort.Slice(created, func(a, b int) bool { return created[a].ID < created[b].ID })
+	data, err := json.MarshalIndent(map[string][]itemData{"items": created}, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	for _, id := range created {
+		w.itemFiles[id.ID] = path
+	}
+	return nil
+}
+
+// puts back whatever was lying around at the last shutdown, and opens or locks doors as they were left
+func (w *World) loadState() error {
+	data, err := os.ReadFile(cfg.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ws := worldState{}
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return fmt.Errorf("%s: %v", cfg.StateFile, err)
+	}
+	for _, rs := range ws.Rooms {
+		r := getRoomByID(rs.Room, w)
+		if r == nil {
+			log.Printf("Room %d in %s no longer exists, dropping its items", rs.Room, cfg.StateFile)
+			continue
+		}
+		for _, si := range rs.Items {
+			if i := si.restore(r, w); i != nil {
+				r.add(i)
+			}
+		}
+	}
+	for _, ds := range ws.Doors {
+		if r := getRoomByID(ds.Room, w); r != nil {
+			if ex := r.getExit(ds.Exit); ex != nil && ex.door != nil {
+				ex.door.setState(ds.State)
+			}
+		}
+	}
+	return nil
+}
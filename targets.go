@@ -0,0 +1,227 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// places a target can be looked for, searched in this order
+const (
+	scopeChars int = 1 << iota
+	scopeInv
+	scopeFloor
+	scopeEq
+
+	scopeItems int = scopeInv | scopeFloor | scopeEq
+	scopeAll   int = scopeChars | scopeItems
+)
+
+// what the player typed to pick something out: '2.cap' is the second cap, 'all' is everything
+// and 'all.cap' every cap. each word has to start one of the thing's keywords
+type target struct {
+	all   bool
+	nth   int
+	words []string
+}
+
+// something a target resolved to, either a character or an item
+type found struct {
+	char  *Character
+	item  *Item
+	scope int
+}
+
+func parseTarget(s string) target {
+	t := target{nth: 1}
+	s = strings.ToLower(strings.Trim(strings.TrimSpace(s), "\"'"))
+	if s == "all" {
+		t.all = true
+		return t
+	}
+	if strings.HasPrefix(s, "all.") {
+		t.all = true
+		s = s[4:]
+	} else if n := strings.Index(s, "."); n > 0 {
+		if num, err := strconv.Atoi(s[:n]); err == nil && num > 0 {
+			t.nth = num
+			s = s[n+1:]
+		}
+	}
+	t.words = strings.Fields(s)
+	return t
+}
+
+func (t target) matches(keywords []string) bool {
+	for _, w := range t.words {
+		ok := false
+		for _, k := range keywords {
+			if strings.HasPrefix(k, w) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// splits on spaces, keeping anything in double or single quotes together
+func splitArgs(s string) []string {
+	args := []string{}
+	cur := strings.Builder{}
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inArg = true
+		case quote == 0 && r == ' ':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// words that never pick out one thing from another
+var noiseWords = map[string]bool{"a": true, "an": true, "the": true, "some": true, "of": true}
+
+func nameKeywords(name string) []string {
+	kw := []string{}
+	for _, k := range strings.Fields(strings.ToLower(name)) {
+		if !noiseWords[k] {
+			kw = append(kw, k)
+		}
+	}
+	return kw
+}
+
+// fills in whatever descriptions an item was given without: keywords from its name, its name
+// as the short description and a plain 'lying here' line built from that
+func (i *Item) fillDescs() {
+	if len(i.keywords) == 0 {
+		i.keywords = nameKeywords(i.name)
+	}
+	if i.short == "" {
+		i.short = i.name
+	}
+	if i.long == "" {
+		i.long = capFirst(i.short) + " is lying here."
+	}
+}
+
+// finds what arg refers to in the given scopes: the nth match for a single target,
+// every match for 'all'. usr is never a match for themselves
+func (usr *User) resolve(arg string, scopes int, w *World) []found {
+	t := parseTarget(arg)
+	res := []found{}
+	if !t.all && len(t.words) == 0 {
+		return res
+	}
+	n := 0
+	// returns true once the single target asked for has been found
+	add := func(f found) bool {
+		n++
+		if t.all {
+			res = append(res, f)
+			return false
+		}
+		if n == t.nth {
+			res = append(res, f)
+			return true
+		}
+		return false
+	}
+	if scopes&scopeChars != 0 {
+		for _, u := range usr.room.users {
+			if u != usr && t.matches(nameKeywords(u.name)) && add(found{char: u.char, scope: scopeChars}) {
+				return res
+			}
+		}
+		for _, m := range usr.room.mobs {
+			if t.matches(nameKeywords(m.name)) && add(found{char: m.char, scope: scopeChars}) {
+				return res
+			}
+		}
+	}
+	items := func(list []*Item, scope int) bool {
+		for _, i := range list {
+			if t.matches(i.keywords) && add(found{item: i, scope: scope}) {
+				return true
+			}
+		}
+		return false
+	}
+	if scopes&scopeInv != 0 && items(usr.char.inv, scopeInv) {
+		return res
+	}
+	if scopes&scopeFloor != 0 && items(usr.room.items, scopeFloor) {
+		return res
+	}
+	if scopes&scopeEq != 0 {
+		worn := []*Item{}
+		for _, s := range w.eqList {
+			if i := usr.char.eq[s]; i != nil {
+				worn = append(worn, i)
+			}
+		}
+		items(worn, scopeEq)
+	}
+	return res
+}
+
+// the items arg refers to in scopes
+func (usr *User) resolveItems(arg string, scopes int, w *World) []*Item {
+	items := []*Item{}
+	for _, f := range usr.resolve(arg, scopes&scopeItems, w) {
+		items = append(items, f.item)
+	}
+	return items
+}
+
+// the one character arg refers to in the room, nil if there isn't one
+func (usr *User) resolveChar(arg string, w *World) *Character {
+	if parseTarget(arg).all {
+		return nil
+	}
+	if f := usr.resolve(arg, scopeChars, w); len(f) > 0 {
+		return f[0].char
+	}
+	return nil
+}
+
+// like resolve, for a list of items that isn't one of the usual scopes, such as what's inside a container
+func resolveIn(arg string, list []*Item) []*Item {
+	t := parseTarget(arg)
+	res := []*Item{}
+	if !t.all && len(t.words) == 0 {
+		return res
+	}
+	n := 0
+	for _, i := range list {
+		if !t.matches(i.keywords) {
+			continue
+		}
+		n++
+		if t.all {
+			res = append(res, i)
+		} else if n == t.nth {
+			return append(res, i)
+		}
+	}
+	return res
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+)
+
+// telnet commands and options, RFC 854 and friends
+const (
+	tnSE   byte = 240
+	tnNOP  byte = 241
+	tnSB   byte = 250
+	tnWILL byte = 251
+	tnWONT byte = 252
+	tnDO   byte = 253
+	tnDONT byte = 254
+	tnIAC  byte = 255
+
+	tnOptEcho  byte = 1
+	tnOptSGA   byte = 3
+	tnOptTType byte = 24
+	tnOptNAWS  byte = 31
+
+	tnTTypeIs   byte = 0
+	tnTTypeSend byte = 1
+
+	maxLineLength   int = 4096
+	maxSubnegLength int = 256
+)
+
+// parser states
+const (
+	tnData = iota
+	tnGotIAC
+	tnGotCmd
+	tnSubneg
+	tnSubnegIAC
+)
+
+// strips and answers telnet negotiation, hands back complete lines however the client splits or ends them
+type telnet struct {
+	conn   net.Conn
+	write  func([]byte) error
+	buf    []byte
+	line   []byte
+	lines  []string
+	state  int
+	cmd    byte
+	sbOpt  byte
+	sbData []byte
+	cr     bool
+
+	// last WILL/WONT we sent for our side of an option, DO/DONT for theirs
+	us   map[byte]byte
+	them map[byte]byte
+
+	width  int
+	height int
+	ttype  string
+}
+
+// reads come straight off conn, replies go out through write so they stay in order with other output
+func newTelnet(conn net.Conn, write func([]byte) error) *telnet {
+	return &telnet{
+		conn:  conn,
+		write: write,
+		buf:   make([]byte, 4096),
+		us:    make(map[byte]byte),
+		them:  make(map[byte]byte),
+	}
+}
+
+// asks the client for the options we care about
+func (t *telnet) negotiate() {
+	t.send(tnWILL, tnOptSGA)
+	t.send(tnDO, tnOptNAWS)
+	t.send(tnDO, tnOptTType)
+}
+
+func (t *telnet) send(cmd byte, opt byte) {
+	switch cmd {
+	case tnWILL, tnWONT:
+		t.us[opt] = cmd
+	case tnDO, tnDONT:
+		t.them[opt] = cmd
+	}
+	t.write([]byte{tnIAC, cmd, opt})
+}
+
+// server side echo on means the client stops echoing locally, which hides passwords
+func (t *telnet) setEcho(serverEchoes bool) {
+	if serverEchoes {
+		t.send(tnWILL, tnOptEcho)
+	} else {
+		t.send(tnWONT, tnOptEcho)
+	}
+}
+
+// returns the next complete line, without its line ending
+func (t *telnet) readLine() (string, error) {
+	for len(t.lines) == 0 {
+		n, err := t.conn.Read(t.buf)
+		if err != nil {
+			return "", err
+		}
+		t.feed(t.buf[:n])
+	}
+	line := t.lines[0]
+	t.lines = t.lines[1:]
+	return line, nil
+}
+
+func (t *telnet) feed(data []byte) {
+	for _, b := range data {
+		switch t.state {
+		case tnData:
+			if b == tnIAC {
+				t.state = tnGotIAC
+				continue
+			}
+			t.data(b)
+		case tnGotIAC:
+			switch b {
+			case tnIAC:
+				t.state = tnData
+				t.data(b)
+			case tnWILL, tnWONT, tnDO, tnDONT:
+				t.cmd = b
+				t.state = tnGotCmd
+			case tnSB:
+				t.sbData = t.sbData[:0]
+				t.state = tnSubneg
+				t.cmd = 0
+			default:
+				// NOP, GA, AYT and friends need nothing from us
+				t.state = tnData
+			}
+		case tnGotCmd:
+			t.option(t.cmd, b)
+			t.state = tnData
+		case tnSubneg:
+			if b == tnIAC {
+				t.state = tnSubnegIAC
+				continue
+			}
+			if t.cmd == 0 {
+				// first byte of a subnegotiation is the option it is about
+				t.sbOpt = b
+				t.cmd = tnSB
+				continue
+			}
+			if len(t.sbData) < maxSubnegLength {
+				t.sbData = append(t.sbData, b)
+			}
+		case tnSubnegIAC:
+			switch b {
+			case tnSE:
+				t.subneg(t.sbOpt, t.sbData)
+				t.state = tnData
+			case tnIAC:
+				// an escaped IAC is data too, so it counts toward the same limit
+				if len(t.sbData) < maxSubnegLength {
+					t.sbData = append(t.sbData, b)
+				}
+				t.state = tnSubneg
+			default:
+				t.state = tnSubneg
+			}
+		}
+	}
+}
+
+// plain bytes, CR LF, CR NUL, lone CR and lone LF all end a line
+func (t *telnet) data(b byte) {
+	if t.cr {
+		t.cr = false
+		if b == '\n' || b == 0 {
+			return
+		}
+	}
+	switch b {
+	case '\r':
+		t.cr = true
+		t.endLine()
+	case '\n':
+		t.endLine()
+	case 8, 127:
+		if len(t.line) > 0 {
+			t.line = t.line[:len(t.line)-1]
+		}
+	case 0:
+	default:
+		if b < 32 && b != '\t' {
+			return
+		}
+		if len(t.line) < maxLineLength {
+			t.line = append(t.line, b)
+		}
+	}
+}
+
+func (t *telnet) endLine() {
+	t.lines = append(t.lines, strings.TrimSpace(string(t.line)))
+	t.line = t.line[:0]
+}
+
+// answers a WILL/WONT/DO/DONT from the client, staying quiet when it's agreeing with something we asked for
+func (t *telnet) option(cmd byte, opt byte) {
+	switch cmd {
+	case tnDO, tnDONT:
+		want := tnWONT
+		if cmd == tnDO && (opt == tnOptSGA || opt == tnOptEcho) {
+			want = tnWILL
+		}
+		if opt == tnOptEcho && cmd == tnDO && t.us[opt] != tnWILL {
+			// only echo while we're hiding a password
+			want = tnWONT
+		}
+		if t.us[opt] != want {
+			t.send(want, opt)
+		}
+	case tnWILL, tnWONT:
+		want := tnDONT
+		if cmd == tnWILL && (opt == tnOptNAWS || opt == tnOptTType) {
+			want = tnDO
+		}
+		if t.them[opt] != want {
+			t.send(want, opt)
+		}
+		if cmd == tnWILL && opt == tnOptTType {
+			t.write([]byte{tnIAC, tnSB, tnOptTType, tnTTypeSend, tnIAC, tnSE})
+		}
+	}
+}
+
+func (t *telnet) subneg(opt byte, data []byte) {
+	switch opt {
+	case tnOptNAWS:
+		if len(data) >= 4 {
+			t.width = int(binary.BigEndian.Uint16(data[0:2]))
+			t.height = int(binary.BigEndian.Uint16(data[2:4]))
+		}
+	case tnOptTType:
+		if len(data) > 1 && data[0] == tnTTypeIs {
+			t.ttype = string(data[1:])
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// a subnegotiation made of escaped IACs is cut off at the same length as any other
+func TestSubnegEscapedIACLimit(t *testing.T) {
+	tn := newTelnet(nil, func([]byte) error { return nil })
+	data := []byte{tnIAC, tnSB, tnOptTType, tnTTypeIs}
+	data = append(data, bytes.Repeat([]byte{tnIAC, tnIAC}, 1000)...)
+	data = append(data, tnIAC, tnSE)
+	data = append(data, "look\r\n"...)
+	tn.feed(data)
+
+	if len(tn.sbData) != maxSubnegLength {
+		t.Errorf("kept %d bytes of subnegotiation, want %d", len(tn.sbData), maxSubnegLength)
+	}
+	if want := string(bytes.Repeat([]byte{tnIAC}, maxSubnegLength-1)); tn.ttype != want {
+		t.Errorf("terminal type is %d bytes, want %d", len(tn.ttype), len(want))
+	}
+	if len(tn.lines) != 1 || tn.lines[0] != "look" {
+		t.Errorf("lines after the subnegotiation: %q", tn.lines)
+	}
+}